@@ -0,0 +1,249 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+// Command shows lists Run Boy Run shows the same way the site sees them: from SongKick,
+// Bandsintown (with -bandsintown-app-id) and shows added by hand, merged and then
+// corrected by the same overrides, venue types and time zones files as the site's data.
+//
+// Upcoming shows are listed by default, use -past for the gigography. Shows can be
+// narrowed down by -year, -region and -type, and written as a table, JSON, CSV or iCal.
+//
+//	shows -songkick-artist-id 1234 -songkick-api-key KEY -past -year 2013 -format csv
+//	shows -songkick-artist-id 1234 -songkick-api-key KEY -time-zones data/time-zones.json
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jessecarl/www.runboyrunband.com/content"
+	"github.com/jessecarl/www.runboyrunband.com/shows"
+
+	"github.com/lazyengineering/gobase/envflag"
+)
+
+var (
+	SongkickArtistID  = flag.Int("songkick-artist-id", 0, "Songkick Artist ID")
+	SongkickApiKey    = flag.String("songkick-api-key", "", "Songkick API Key")
+	SongkickMode      = flag.String("songkick-mode", "", "Set to record to save Songkick responses, or replay to use saved responses instead of the API")
+	SongkickFixtures  = flag.String("songkick-fixtures", "", "Folder of saved Songkick responses, for -songkick-mode")
+	BandsintownArtist = flag.String("bandsintown-artist", "Run Boy Run", "Bandsintown Artist Name")
+	BandsintownAppID  = flag.String("bandsintown-app-id", "", "Bandsintown App ID, leave empty to skip Bandsintown")
+	ShowsJSON         = flag.String("shows-json", "", "JSON file of shows added by hand, as in shows.json")
+	Overrides         = flag.String("overrides", "", "JSON file of corrections to shows, as in shows-overrides.json")
+	VenueTypes        = flag.String("venue-types", "", "JSON file of event types by venue ID, as in venue-types.json")
+	TimeZones         = flag.String("time-zones", "", "JSON file of time zones by venue, region or country, as in time-zones.json")
+	Past              = flag.Bool("past", false, "List past shows instead of upcoming shows")
+	Limit             = flag.Int("limit", 0, "Most shows to fetch, 0 for all of them")
+	Year              = flag.Int("year", 0, "Only list shows in this year")
+	Region            = flag.String("region", "", "Only list shows in this state or province, e.g. VA")
+	Type              = flag.String("type", "", "Only list shows of this type: festival, concert, listening-room or club")
+	Format            = flag.String("format", "table", "Output format: table, json, csv or ics")
+	Timeout           = flag.Duration("timeout", 2*time.Minute, "How long to wait for SongKick and Bandsintown")
+)
+
+// Writes events to w in a single format
+type writer func(w io.Writer, events []shows.Event) error
+
+var formats = map[string]writer{
+	"table": writeTable,
+	"json":  writeJSON,
+	"csv":   writeCSV,
+	"ics":   writeICal,
+}
+
+func main() {
+	log.SetFlags(0)
+	envflag.Parse(envflag.FlagMap{})
+	if err := run(os.Stdout); err != nil {
+		log.Println("\x1b[1;31mError:\x1b[0m", err)
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer) error {
+	write, ok := formats[*Format]
+	if !ok {
+		return errors.New("unknown format " + *Format)
+	}
+	filter, err := filters()
+	if err != nil {
+		return err
+	}
+	var opts []shows.Option
+	if rt, err := shows.FixtureTransport(*SongkickMode, *SongkickFixtures); err != nil {
+		return err
+	} else if rt != nil {
+		opts = append(opts, shows.HTTPClient(&http.Client{Transport: rt}))
+	}
+	sources := []shows.Source{shows.New(*SongkickArtistID, *SongkickApiKey, opts...)}
+	if len(*BandsintownAppID) > 0 {
+		sources = append(sources, shows.NewBandsintown(*BandsintownArtist, *BandsintownAppID, nil))
+	}
+	sources = append(sources, shows.JSONSource(func() ([]byte, error) {
+		if len(*ShowsJSON) == 0 {
+			return nil, nil // nothing added by hand
+		}
+		return ioutil.ReadFile(*ShowsJSON)
+	}))
+	gigs := shows.Merge(sources...)
+	for _, f := range []struct {
+		name  string
+		proto shows.Applier
+	}{
+		{*Overrides, shows.Overrides(nil)},
+		{*VenueTypes, shows.VenueTypes(nil)},
+		{*TimeZones, shows.TimeZones{}},
+	} {
+		a, err := readJSON(f.name, f.proto)
+		if err != nil {
+			return err
+		}
+		gigs = shows.With(gigs, func() (shows.Applier, error) { return a, nil })
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *Timeout)
+	defer cancel()
+	var events []shows.Event
+	if *Past {
+		events, err = gigs.PastContext(ctx, *Limit)
+	} else {
+		events, err = gigs.UpcomingContext(ctx, *Limit)
+	}
+	if err != nil {
+		return err
+	}
+	return write(w, filter(events))
+}
+
+// Decodes a JSON file like proto, or returns proto itself if there is no file name
+func readJSON(name string, proto shows.Applier) (shows.Applier, error) {
+	if len(name) == 0 {
+		return proto, nil
+	}
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := content.JSON(proto)(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return v.(shows.Applier), nil
+}
+
+// Builds a function applying the -year, -region and -type filters
+func filters() (func([]shows.Event) []shows.Event, error) {
+	var (
+		q     = shows.Query{Year: *Year}
+		typed bool
+		t     shows.EventType
+	)
+	if len(*Type) > 0 {
+		var err error
+		if t, err = shows.ParseEventType(*Type); err != nil {
+			return nil, err
+		}
+		typed = true
+	}
+	return func(events []shows.Event) []shows.Event {
+		events = q.Filter(events)
+		if typed {
+			events = shows.FilterType(events, t)
+		}
+		if len(*Region) > 0 {
+			inRegion := make([]shows.Event, 0, len(events))
+			for _, e := range events {
+				if strings.EqualFold(e.Location.Address.AddressRegion, *Region) {
+					inRegion = append(inRegion, e)
+				}
+			}
+			events = inRegion
+		}
+		return events
+	}, nil
+}
+
+// Date of an event for display, e.g. "2013-08-02" or "2013-08-02–2013-08-04"
+func dates(e shows.Event) string {
+	d := e.StartDate.Format("2006-01-02")
+	if !e.EndDate.IsZero() && e.EndDate.Format("2006-01-02") != d {
+		d += "–" + e.EndDate.Format("2006-01-02")
+	}
+	return d
+}
+
+func writeTable(w io.Writer, events []shows.Event) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tVENUE\tCITY\tREGION\tCOUNTRY\tTYPE\tBILLING")
+	for _, e := range events {
+		a := e.Location.Address
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			dates(e), e.Location.Name, a.AddressLocality, a.AddressRegion, a.AddressCountry, e.Type, e.Billing)
+	}
+	if len(events) == 1 {
+		fmt.Fprintln(tw, "\n1 show")
+	} else {
+		fmt.Fprintf(tw, "\n%d shows\n", len(events))
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, events []shows.Event) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(events)
+}
+
+func writeCSV(w io.Writer, events []shows.Event) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "start", "end", "name", "venue", "city", "region", "country", "type", "billing", "performers", "url"})
+	for _, e := range events {
+		var end string
+		if !e.EndDate.IsZero() {
+			end = e.EndDate.Format("2006-01-02")
+		}
+		performers := make([]string, 0, len(e.Performer))
+		for _, p := range e.Performer {
+			performers = append(performers, p.Name)
+		}
+		a := e.Location.Address
+		cw.Write([]string{
+			e.ID,
+			e.StartDate.Format("2006-01-02"),
+			end,
+			e.Name,
+			e.Location.Name,
+			a.AddressLocality,
+			a.AddressRegion,
+			a.AddressCountry,
+			e.Type.String(),
+			e.Billing.String(),
+			strings.Join(performers, "; "),
+			e.SameAs,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeICal(w io.Writer, events []shows.Event) error {
+	_, err := shows.ICalendar{
+		Name:   "Run Boy Run",
+		Domain: "runboyrunband.com",
+		Events: events,
+	}.WriteTo(w)
+	return err
+}
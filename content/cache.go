@@ -0,0 +1,143 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package content
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Turns raw data into something useful to the site, e.g. by unmarshaling JSON
+type DecodeFunc func([]byte) (interface{}, error)
+
+// Returns a DecodeFunc that unmarshals JSON into a new value of the same type as proto,
+// e.g. JSON([]quote(nil)) decodes into a []quote
+func JSON(proto interface{}) DecodeFunc {
+	t := reflect.TypeOf(proto)
+	return func(b []byte) (interface{}, error) {
+		v := reflect.New(t)
+		if err := json.Unmarshal(b, v.Interface()); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	}
+}
+
+// Cache keeps data (and its decoded form) from another Store in memory.
+//
+// Once an entry is older than the TTL, it is refreshed in the background while the
+// stale copy continues to be served. If the refresh fails, the stale copy is kept.
+// Missing keys are remembered the same way, so optional data costs nothing when absent.
+type Cache struct {
+	store   Store
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	data       []byte
+	version    string
+	fetched    time.Time
+	refreshing bool
+	decoded    bool
+	value      interface{}
+	missing    bool // the Store had nothing for the key
+}
+
+// Create a new Cache in front of a Store
+func NewCache(s Store, ttl time.Duration) *Cache {
+	return &Cache{
+		store:   s,
+		ttl:     ttl,
+		entries: make(map[string]*entry),
+	}
+}
+
+func (c *Cache) Get(key string) ([]byte, error) {
+	e, err := c.entry(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.data, nil
+}
+
+// Returns the decoded data for key. The decoded value is kept until the underlying data
+// changes, so the same DecodeFunc should always be used for a given key.
+func (c *Cache) Value(key string, decode DecodeFunc) (interface{}, error) {
+	e, err := c.entry(key)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !e.decoded {
+		v, err := decode(e.data)
+		if err != nil {
+			return nil, err
+		}
+		e.value, e.decoded = v, true
+	}
+	return e.value, nil
+}
+
+func (c *Cache) entry(key string) (*entry, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		if time.Since(e.fetched) > c.ttl && !e.refreshing {
+			e.refreshing = true
+			go c.refresh(key, e.version)
+		}
+		c.mu.Unlock()
+		return e.found()
+	}
+	c.mu.Unlock()
+
+	// nothing to serve yet, so the first read has to wait
+	data, version, err := c.fetch(key, "")
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	e := &entry{data: data, version: version, fetched: time.Now(), missing: err == ErrNotFound}
+	c.mu.Lock()
+	c.entries[key] = e
+	c.mu.Unlock()
+	return e.found()
+}
+
+func (e *entry) found() (*entry, error) {
+	if e.missing {
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+func (c *Cache) refresh(key, version string) {
+	data, version, err := c.fetch(key, version)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[key]
+	e.refreshing = false
+	switch {
+	case err == ErrNotModified:
+		e.fetched = time.Now()
+	case err == ErrNotFound:
+		c.entries[key] = &entry{fetched: time.Now(), missing: true}
+	case err != nil:
+		log.Println("\x1b[1;31mStale:\x1b[0m", key, err)
+	default:
+		c.entries[key] = &entry{data: data, version: version, fetched: time.Now()}
+	}
+}
+
+func (c *Cache) fetch(key, version string) ([]byte, string, error) {
+	if vs, ok := c.store.(VersionedStore); ok {
+		return vs.GetVersion(key, version)
+	}
+	data, err := c.store.Get(key)
+	return data, "", err
+}
@@ -0,0 +1,202 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package content
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testTTL = 20 * time.Millisecond
+
+// A Memory store that counts reads, and can fail or version them like S3 with ETags
+type testStore struct {
+	mu        sync.Mutex
+	data      Memory
+	versioned bool
+	fail      error
+	gets      int
+	full      int // reads that returned data, i.e. weren't ErrNotModified
+}
+
+func (s *testStore) Get(key string) ([]byte, error) {
+	b, _, err := s.GetVersion(key, "")
+	return b, err
+}
+
+func (s *testStore) GetVersion(key, version string) ([]byte, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gets++
+	if s.fail != nil {
+		return nil, "", s.fail
+	}
+	b, err := s.data.Get(key)
+	if err != nil {
+		return nil, "", err
+	}
+	etag := ""
+	if s.versioned {
+		etag = strconv.Itoa(len(b)) + ":" + string(b)
+		if version == etag {
+			return nil, version, ErrNotModified
+		}
+	}
+	s.full++
+	return b, etag, nil
+}
+
+func (s *testStore) set(key, value string, fail error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(value) > 0 {
+		s.data[key] = []byte(value)
+	} else {
+		delete(s.data, key)
+	}
+	s.fail = fail
+}
+
+func (s *testStore) counts() (gets, full int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gets, s.full
+}
+
+// Waits for the background refresh started by a read of a stale entry
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !done(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("gave up waiting for %s", what)
+		}
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	s := &testStore{data: Memory{"bio.md": []byte("old")}}
+	c := NewCache(s, testTTL)
+	for i := 0; i < 3; i++ {
+		if b, err := c.Get("bio.md"); err != nil || string(b) != "old" {
+			t.Fatalf("got %q, %v", b, err)
+		}
+	}
+	if gets, _ := s.counts(); gets != 1 {
+		t.Errorf("read the store %d times within the TTL, want 1", gets)
+	}
+
+	s.set("bio.md", "new", nil)
+	time.Sleep(testTTL)
+	if b, _ := c.Get("bio.md"); string(b) != "old" {
+		t.Errorf("got %q once expired, want the stale copy while refreshing", b)
+	}
+	waitFor(t, "the refresh", func() bool {
+		b, _ := c.Get("bio.md")
+		return string(b) == "new"
+	})
+	if gets, _ := s.counts(); gets != 2 {
+		t.Errorf("read the store %d times, want 2", gets)
+	}
+}
+
+func TestCacheNotModified(t *testing.T) {
+	s := &testStore{data: Memory{"quotes.json": []byte(`["a", "b"]`)}, versioned: true}
+	c := NewCache(s, testTTL)
+	decodes := 0
+	decode := func(b []byte) (interface{}, error) {
+		decodes++
+		return JSON([]string(nil))(b)
+	}
+	v, err := c.Value("quotes.json", decode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := v.([]string); len(q) != 2 || q[1] != "b" {
+		t.Errorf("decoded %v", q)
+	}
+
+	time.Sleep(testTTL)
+	c.Value("quotes.json", decode)
+	waitFor(t, "the refresh", func() bool {
+		gets, _ := s.counts()
+		return gets == 2
+	})
+	if _, full := s.counts(); full != 1 {
+		t.Errorf("downloaded unchanged data %d times, want 1", full)
+	}
+	c.Value("quotes.json", decode)
+	if decodes != 1 {
+		t.Errorf("decoded unchanged data %d times, want 1", decodes)
+	}
+	// not modified counts as fresh, so there's no refresh until the TTL is up again
+	c.Value("quotes.json", decode)
+	if gets, _ := s.counts(); gets != 2 {
+		t.Errorf("read the store %d times, want 2", gets)
+	}
+}
+
+func TestCacheStale(t *testing.T) {
+	s := &testStore{data: Memory{"teaser.md": []byte("hello")}}
+	c := NewCache(s, testTTL)
+	c.Get("teaser.md")
+
+	s.set("teaser.md", "hello", errors.New("S3 is down"))
+	time.Sleep(testTTL)
+	c.Get("teaser.md")
+	waitFor(t, "the failed refresh", func() bool {
+		gets, _ := s.counts()
+		return gets == 2
+	})
+	if b, err := c.Get("teaser.md"); err != nil || string(b) != "hello" {
+		t.Errorf("got %q, %v after a failed refresh, want the stale copy", b, err)
+	}
+}
+
+func TestCacheNotFound(t *testing.T) {
+	s := &testStore{data: Memory{}}
+	c := NewCache(s, testTTL)
+	for i := 0; i < 3; i++ {
+		if _, err := c.Value("shows.json", JSON([]string(nil))); err != ErrNotFound {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+	}
+	if gets, _ := s.counts(); gets != 1 {
+		t.Errorf("looked for missing data %d times within the TTL, want 1", gets)
+	}
+
+	// added later
+	s.set("shows.json", `["x"]`, nil)
+	time.Sleep(testTTL)
+	c.Get("shows.json")
+	waitFor(t, "the refresh", func() bool {
+		_, err := c.Get("shows.json")
+		return err == nil
+	})
+
+	// and removed again
+	s.set("shows.json", "", nil)
+	time.Sleep(testTTL)
+	c.Get("shows.json")
+	waitFor(t, "the refresh", func() bool {
+		_, err := c.Get("shows.json")
+		return err == ErrNotFound
+	})
+}
+
+func TestCacheError(t *testing.T) {
+	s := &testStore{data: Memory{}, fail: errors.New("S3 is down")}
+	c := NewCache(s, testTTL)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get("bio.md"); err == nil || err == ErrNotFound {
+			t.Errorf("got %v, want the store's error", err)
+		}
+	}
+	// errors aren't cached, only missing data
+	if gets, _ := s.counts(); gets != 2 {
+		t.Errorf("read the store %d times, want 2", gets)
+	}
+}
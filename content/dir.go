@@ -0,0 +1,25 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package content
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Store backed by a local directory, laid out just like the S3 bucket.
+// Useful for running the site offline.
+type Dir string
+
+func (d Dir) Get(key string) ([]byte, error) {
+	// keep keys from wandering outside of the directory
+	name := filepath.Join(string(d), filepath.FromSlash(path.Clean("/"+key)))
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return b, err
+}
@@ -0,0 +1,15 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package content
+
+// Store kept entirely in memory, mostly useful for injecting fixtures
+type Memory map[string][]byte
+
+func (m Memory) Get(key string) ([]byte, error) {
+	b, ok := m[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return b, nil
+}
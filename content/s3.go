@@ -0,0 +1,69 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package content
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Store backed by an AWS S3 bucket. All keys are relative to Prefix.
+type S3 struct {
+	Bucket string
+	Prefix string
+	svc    *s3.S3
+}
+
+// Create a new S3 Store sharing a single AWS session
+func NewS3(bucket, prefix string) *S3 {
+	return &S3{
+		Bucket: bucket,
+		Prefix: prefix,
+		svc:    s3.New(session.New()),
+	}
+}
+
+func (s *S3) Get(key string) ([]byte, error) {
+	b, _, err := s.GetVersion(key, "")
+	return b, err
+}
+
+// Uses the object ETag as its version, so unchanged objects are never downloaded twice
+func (s *S3) GetVersion(key, version string) ([]byte, string, error) {
+	// assume we don't need multi-part downloads for this kind of data
+	t := time.Now()
+	defer func() {
+		log.Printf("\x1b[1;35mGetObject:\x1b[0m \x1b[34m%12d\x1b[0mµs \x1b[33m%s\x1b[0m", time.Since(t)/1000, s.Prefix+key)
+	}()
+	in := &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Prefix + key),
+	}
+	if len(version) > 0 {
+		in.IfNoneMatch = aws.String(version)
+	}
+	resp, err := s.svc.GetObject(in)
+	if err != nil {
+		if rerr, ok := err.(awserr.RequestFailure); ok && rerr.StatusCode() == http.StatusNotModified {
+			return nil, version, ErrNotModified
+		}
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NoSuchKey" {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, aws.StringValue(resp.ETag), nil
+}
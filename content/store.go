@@ -0,0 +1,29 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+// Package content provides the site data (JSON and Markdown documents)
+// independent of where it happens to be kept.
+package content
+
+import (
+	"errors"
+)
+
+var (
+	// Returned by a Store when there is nothing stored under the requested key
+	ErrNotFound = errors.New("content: not found")
+	// Returned by a VersionedStore when data has not changed since the given version
+	ErrNotModified = errors.New("content: not modified")
+)
+
+// A Store retrieves site data by key, e.g. "bio.md" or "albums.json"
+type Store interface {
+	Get(key string) ([]byte, error)
+}
+
+// A VersionedStore can tell when data has changed, and skip reading it when it hasn't.
+// Versions are opaque strings, like an ETag.
+type VersionedStore interface {
+	Store
+	GetVersion(key, version string) (data []byte, newVersion string, err error)
+}
@@ -4,10 +4,23 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net/http"
 )
 
+// Returned by actions when the requested page does not exist
+var ErrNotFound = errors.New("page not found")
+
+// Serves a 404 when the action could not find what was requested, and a 500 otherwise
+func ErrorPage(res http.ResponseWriter, req *http.Request, err error) {
+	if err == ErrNotFound {
+		Error404(res, req)
+		return
+	}
+	Error500(res, req, err)
+}
+
 const page500 = `<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -0,0 +1,230 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+// Package jsonld describes the band, its albums and its shows as schema.org JSON-LD,
+// so search engines can show rich results for them.
+//
+// Nodes are meant to be rendered by html/template inside of a
+// <script type="application/ld+json"> element, which takes care of escaping.
+package jsonld
+
+import (
+	"time"
+
+	"github.com/jessecarl/www.runboyrunband.com/shows"
+)
+
+const schemaContext = "http://schema.org"
+
+// A single JSON-LD object
+type Node map[string]interface{}
+
+// Marks n as a top level node, which needs its own context
+func top(n Node) Node {
+	n["@context"] = schemaContext
+	return n
+}
+
+// Returns a MusicEvent node for each event
+func Events(events []shows.Event) []Node {
+	nodes := make([]Node, 0, len(events))
+	for _, e := range events {
+		nodes = append(nodes, Event(e))
+	}
+	return nodes
+}
+
+// Describes a single event as a http://schema.org/MusicEvent
+func Event(e shows.Event) Node {
+	n := Node{
+		"@type":     "MusicEvent",
+		"name":      e.Name,
+		"startDate": date(e.StartDate, e.AllDay),
+		"location":  place(e.Location),
+	}
+	if !e.EndDate.IsZero() {
+		n["endDate"] = date(e.EndDate, e.AllDay)
+	}
+	if len(e.SameAs) > 0 {
+		n["url"] = e.SameAs
+	}
+	if len(e.Description) > 0 {
+		n["description"] = e.Description
+	}
+	if len(e.Image) > 0 {
+		n["image"] = e.Image
+	}
+	// support acts are secondary contributors to the event
+	if h := e.Headliners(); len(h) > 0 {
+		n["performer"] = musicGroups(h)
+	}
+	if s := e.Support(); len(s) > 0 {
+		n["contributor"] = musicGroups(s)
+	}
+	if len(e.Offers) > 0 {
+		offers := make([]Node, 0, len(e.Offers))
+		for _, o := range e.Offers {
+			offers = append(offers, offer(o))
+		}
+		n["offers"] = offers
+	}
+	return top(n)
+}
+
+func offer(o shows.Offer) Node {
+	n := Node{
+		"@type": "Offer",
+		"url":   o.URL,
+	}
+	switch {
+	case o.HighPrice > o.LowPrice:
+		n["@type"] = "AggregateOffer"
+		n["lowPrice"] = o.LowPrice
+		n["highPrice"] = o.HighPrice
+	case o.LowPrice > 0:
+		n["price"] = o.LowPrice
+	}
+	if len(o.PriceCurrency) > 0 {
+		n["priceCurrency"] = o.PriceCurrency
+	}
+	if len(o.Availability) > 0 {
+		n["availability"] = schemaContext + "/" + o.Availability
+	}
+	if !o.ValidFrom.IsZero() {
+		n["validFrom"] = o.ValidFrom.Format(time.RFC3339)
+	}
+	return n
+}
+
+func date(t time.Time, allDay bool) string {
+	if allDay {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+func place(p shows.Place) Node {
+	n := Node{
+		"@type": "Place",
+		"name":  p.Name,
+		"address": Node{
+			"@type":           "PostalAddress",
+			"streetAddress":   p.Address.StreetAddress,
+			"addressLocality": p.Address.AddressLocality,
+			"addressRegion":   p.Address.AddressRegion,
+			"postalCode":      p.Address.PostalCode,
+			"addressCountry":  p.Address.AddressCountry,
+		},
+	}
+	if len(p.SameAs) > 0 {
+		n["sameAs"] = p.SameAs
+	}
+	if p.Geo.Lat != 0 || p.Geo.Lng != 0 {
+		n["geo"] = Node{
+			"@type":     "GeoCoordinates",
+			"latitude":  p.Geo.Lat,
+			"longitude": p.Geo.Lng,
+		}
+	}
+	return n
+}
+
+func musicGroups(groups []shows.MusicGroup) []Node {
+	nodes := make([]Node, 0, len(groups))
+	for _, g := range groups {
+		nodes = append(nodes, musicGroup(g.Name, g.SameAs))
+	}
+	return nodes
+}
+
+func musicGroup(name, sameAs string) Node {
+	n := Node{
+		"@type": "MusicGroup",
+		"name":  name,
+	}
+	if len(sameAs) > 0 {
+		n["sameAs"] = sameAs
+	}
+	return n
+}
+
+// Minimal description of an album. All URLs should be absolute.
+type Album struct {
+	Name          string
+	URL           string
+	Image         string
+	Description   string
+	DatePublished time.Time
+}
+
+// Describes an album by the given artist as a http://schema.org/MusicAlbum
+func MusicAlbum(a Album, byArtist string) Node {
+	n := Node{
+		"@type":    "MusicAlbum",
+		"name":     a.Name,
+		"byArtist": musicGroup(byArtist, ""),
+	}
+	if len(a.URL) > 0 {
+		n["url"] = a.URL
+	}
+	if len(a.Image) > 0 {
+		n["image"] = a.Image
+	}
+	if len(a.Description) > 0 {
+		n["description"] = a.Description
+	}
+	if !a.DatePublished.IsZero() {
+		n["datePublished"] = a.DatePublished.Format("2006-01-02")
+	}
+	return top(n)
+}
+
+// A member of the band, and the role they play in it (e.g. "fiddle")
+type Member struct {
+	Name     string
+	Image    string
+	RoleName string
+}
+
+// The band itself. All URLs should be absolute.
+type Band struct {
+	Name    string
+	URL     string
+	Logo    string
+	SameAs  []string
+	Members []Member
+}
+
+// Describes the band and its members as a http://schema.org/MusicGroup
+func MusicGroup(b Band) Node {
+	n := musicGroup(b.Name, "")
+	if len(b.URL) > 0 {
+		n["url"] = b.URL
+	}
+	if len(b.Logo) > 0 {
+		n["logo"] = b.Logo
+	}
+	if len(b.SameAs) > 0 {
+		n["sameAs"] = b.SameAs
+	}
+	if len(b.Members) > 0 {
+		members := make([]Node, 0, len(b.Members))
+		for _, m := range b.Members {
+			person := Node{
+				"@type": "Person",
+				"name":  m.Name,
+			}
+			if len(m.Image) > 0 {
+				person["image"] = m.Image
+			}
+			// roles let us say who plays what
+			members = append(members, Node{
+				"@type":    "OrganizationRole",
+				"member":   person,
+				"roleName": m.RoleName,
+			})
+		}
+		n["member"] = members
+	}
+	return top(n)
+}
@@ -4,19 +4,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
-	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/jessecarl/www.runboyrunband.com/content"
+	"github.com/jessecarl/www.runboyrunband.com/jsonld"
 	"github.com/jessecarl/www.runboyrunband.com/shows"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	httpgzip "github.com/daaku/go.httpgzip"
 	"github.com/lazyengineering/gobase/envflag"
 	"github.com/lazyengineering/gobase/layouts"
@@ -44,8 +45,16 @@ func init() {
 		HelperTemplateGlob = flag.String("helpers", "static/templates/helpers/*.html", "Pattern for helper templates")
 		SongkickArtistID   = flag.Int("songkick-artist-id", 0, "Songkick Artist ID")
 		SongkickApiKey     = flag.String("songkick-api-key", "", "Songkick API Key")
+		SongkickRefresh    = flag.Duration("songkick-refresh", 15*time.Minute, "How long to keep Songkick events before refreshing them")
+		SongkickMode       = flag.String("songkick-mode", "", "Set to record to save Songkick responses, or replay to use saved responses instead of the API")
+		SongkickFixtures   = flag.String("songkick-fixtures", "", "Folder of saved Songkick responses, for -songkick-mode")
+		BandsintownArtist  = flag.String("bandsintown-artist", "Run Boy Run", "Bandsintown Artist Name")
+		BandsintownAppID   = flag.String("bandsintown-app-id", "", "Bandsintown App ID, leave empty to skip Bandsintown")
+		BandsintownRefresh = flag.Duration("bandsintown-refresh", 15*time.Minute, "How long to keep Bandsintown events before refreshing them")
 		DataBucket         = flag.String("data-bucket", "", "AWS Bucket where data resides")
 		DataKeyPrefix      = flag.String("data-key-prefix", "", "Prefix for all AWS keys in data bucket")
+		DataDir            = flag.String("data-dir", "", "Local folder to read data from instead of the AWS bucket")
+		DataTTL            = flag.Duration("data-ttl", 5*time.Minute, "How long to keep data before refreshing it")
 	)
 
 	// To Parse flags, looking for command-line, then ENV, then defaults
@@ -60,6 +69,15 @@ func init() {
 		log.SetFlags(0)
 	}
 
+	// Site Data
+	var store content.Store
+	if len(*DataDir) > 0 {
+		store = content.Dir(*DataDir)
+	} else {
+		store = content.NewS3(*DataBucket, *DataKeyPrefix)
+	}
+	data := content.NewCache(store, *DataTTL)
+
 	// Static Asset Serving
 	staticServer := NoIndex(func(h http.Handler) http.Handler {
 		// add 1 day caching headers to static assets
@@ -79,7 +97,7 @@ func init() {
 	// Offsite Redirects
 	http.Handle("/e/", http.StripPrefix("/e/", redirect.ServePermanentRedirects(func() map[string]string {
 		m := make(map[string]string)
-		j, err := readFromS3(*DataBucket, *DataKeyPrefix+"redirects.json")
+		j, err := data.Get("redirects.json")
 		if err != nil {
 			// because we're still in bootstrap
 			panic(err)
@@ -104,6 +122,43 @@ func init() {
 		}
 	}
 
+	// Shows
+	var calOpts []shows.Option
+	if rt, err := shows.FixtureTransport(*SongkickMode, *SongkickFixtures); err != nil {
+		// fatal condition
+		panic(err)
+	} else if rt != nil {
+		log.Println("\x1b[1;33mSongkick:\x1b[0m", *SongkickMode, *SongkickFixtures)
+		calOpts = append(calOpts, shows.HTTPClient(&http.Client{Transport: rt, Timeout: 30 * time.Second}))
+	}
+	cal := shows.New(*SongkickArtistID, *SongkickApiKey, calOpts...)
+	cal.SetRefreshInterval(*SongkickRefresh)
+	sources := []shows.Source{cal}
+	if len(*BandsintownAppID) > 0 {
+		bit := shows.NewBandsintown(*BandsintownArtist, *BandsintownAppID, nil)
+		bit.SetRefreshInterval(*BandsintownRefresh)
+		sources = append(sources, bit)
+	}
+	sources = append(sources, shows.JSONSource(func() ([]byte, error) {
+		b, err := data.Get("shows.json")
+		if err == content.ErrNotFound {
+			return nil, nil // nothing added by hand
+		}
+		return b, err
+	}))
+	gigs := shows.With(shows.Merge(sources...), optionalJSON(data, "shows-overrides.json", shows.Overrides(nil)))
+	gigs = shows.With(gigs, optionalJSON(data, "venue-types.json", shows.VenueTypes(nil)))
+	gigs = shows.With(gigs, optionalJSON(data, "time-zones.json", shows.TimeZones{}))
+
+	// Pages with a Leaflet map
+	mapData := staticData(map[string]interface{}{
+		"ExtraCSS": []string{"//cdnjs.cloudflare.com/ajax/libs/leaflet/0.7.7/leaflet.css"},
+		"ExtraJS": []string{
+			"//cdnjs.cloudflare.com/ajax/libs/leaflet/0.7.7/leaflet.js",
+			"/js/map.js",
+		},
+	})
+
 	// Actual Web Application Handlers
 	{
 		HandleNoSubPaths("/", Layout.Act(layouts.MergeActions(
@@ -112,35 +167,67 @@ func init() {
 				"Title":     "Run Boy Run",
 				"BodyClass": "home",
 			}),
-			teaserData(*DataBucket, *DataKeyPrefix),
-			bigNewsData(*DataBucket, *DataKeyPrefix),
+			teaserData(data),
+			bigNewsData(data),
 		), Error500, layouts.LowVolatility, "static/templates/home/*.html"))
 		HandleNoSubPaths("/music/", Layout.Act(layouts.MergeActions(
 			basicData,
 			staticData(map[string]interface{}{"Title": "Run Boy Run – Music"}),
-			musicData(*DataBucket, *DataKeyPrefix),
+			musicData(data),
 		), Error500, layouts.LowVolatility, "static/templates/music/*.html"))
-		HandleNoSubPaths("/shows/", Layout.Act(layouts.MergeActions(
+		Handle("/shows/", showPages(gigs, Layout.Act(layouts.MergeActions(
+			basicData,
+			staticData(map[string]interface{}{
+				"Title":   "Run Boy Run – Shows",
+				"ExtraJS": []string{"/js/near.js"},
+			}),
+			showsData(gigs, cal),
+		), Error500, layouts.LowVolatility, "static/templates/shows/*.html"), Layout.Act(layouts.MergeActions(
+			basicData,
+			mapData,
+			eventData,
+		), ErrorPage, layouts.LowVolatility, "static/templates/event/*.html")))
+		Handle("/shows/calendar.ics", icalHandler(gigs))
+		HandleNoSubPaths("/shows/history/", Layout.Act(layouts.MergeActions(
+			basicData,
+			staticData(map[string]interface{}{"Title": "Run Boy Run – Tour History"}),
+			historyData(gigs),
+		), Error500, layouts.LowVolatility, "static/templates/history/*.html"))
+		HandleNoSubPaths("/shows/past/", Layout.Act(layouts.MergeActions(
+			basicData,
+			pastData(gigs),
+		), ErrorPage, layouts.LowVolatility, "static/templates/past/*.html"))
+		Handle("/shows/map.geojson", geojsonHandler(gigs))
+		HandleNoSubPaths("/shows/map/", Layout.Act(layouts.MergeActions(
 			basicData,
-			staticData(map[string]interface{}{"Title": "Run Boy Run – Shows"}),
-			showsData(*SongkickArtistID, *SongkickApiKey),
-		), Error500, layouts.LowVolatility, "static/templates/shows/*.html"))
+			staticData(map[string]interface{}{"Title": "Run Boy Run – Tour Map"}),
+			mapData,
+		), Error500, layouts.LowVolatility, "static/templates/map/*.html"))
+		Handle("/shows/festivals/", Layout.Act(layouts.MergeActions(
+			basicData,
+			festivalsData(gigs),
+		), ErrorPage, layouts.LowVolatility, "static/templates/festivals/*.html"))
+		Handle("/venues/", Layout.Act(layouts.MergeActions(
+			basicData,
+			mapData,
+			venuesData(gigs),
+		), ErrorPage, layouts.LowVolatility, "static/templates/venues/*.html"))
 		HandleNoSubPaths("/about/", Layout.Act(layouts.MergeActions(
 			basicData,
 			staticData(map[string]interface{}{"Title": "Run Boy Run – About"}),
-			bioData(*DataBucket, *DataKeyPrefix),
-			quoteData(*DataBucket, *DataKeyPrefix),
-			headshotData(*DataBucket, *DataKeyPrefix),
+			bioData(data),
+			quoteData(data),
+			headshotData(data),
 		), Error500, layouts.LowVolatility, "static/templates/about/*.html"))
 		HandleNoSubPaths("/contact/", Layout.Act(layouts.MergeActions(
 			basicData,
 			staticData(map[string]interface{}{"Title": "Run Boy Run – Contact"}),
-			contactData(*DataBucket, *DataKeyPrefix),
+			contactData(data),
 		), Error500, layouts.LowVolatility, "static/templates/contact/*.html"))
 		HandleNoSubPaths("/photos/", Layout.Act(layouts.MergeActions(
 			basicData,
 			staticData(map[string]interface{}{"Title": "Run Boy Run – Photos"}),
-			photosData(*DataBucket, *DataKeyPrefix),
+			photosData(data),
 		), Error500, layouts.LowVolatility, "static/templates/photos/*.html"))
 		HandleNoSubPaths("/videos/", Layout.Act(layouts.MergeActions(
 			basicData,
@@ -217,26 +304,35 @@ func basicData(req *http.Request) (map[string]interface{}, error) {
 	}, nil
 }
 
-func readFromS3(bucket, key string) ([]byte, error) {
-	// assume we don't need multi-part downloads for this kind of data
-	t := time.Now()
-	defer func() {
-		log.Printf("\x1b[1;35mGetObject:\x1b[0m \x1b[34m%12d\x1b[0mµs \x1b[33m%s\x1b[0m", time.Since(t)/1000, key)
-	}()
-	svc := s3.New(session.New())
-	resp, err := svc.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
+// Loads JSON data that may not exist for shows.With, using proto when it doesn't
+func optionalJSON(data *content.Cache, key string, proto shows.Applier) func() (shows.Applier, error) {
+	return func() (shows.Applier, error) {
+		v, err := data.Value(key, content.JSON(proto))
+		if err == content.ErrNotFound {
+			return proto, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return v.(shows.Applier), nil
+	}
+}
+
+// Resolves a site-relative reference (e.g. "/img/rbr-logo.png") to an absolute URL
+func absURL(req *http.Request, ref string) string {
+	base := &url.URL{Scheme: "http", Host: req.Host}
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		base.Scheme = "https"
+	}
+	u, err := base.Parse(ref)
 	if err != nil {
-		return nil, err
+		return ref
 	}
-	return ioutil.ReadAll(resp.Body)
+	return u.String()
 }
 
-func teaserData(bucket, prefix string) layouts.Action {
+func teaserData(data *content.Cache) layouts.Action {
 	return func(req *http.Request) (map[string]interface{}, error) {
-		teaser, err := readFromS3(bucket, prefix+"teaser.md")
+		teaser, err := data.Get("teaser.md")
 		if err != nil {
 			return nil, err
 		}
@@ -246,7 +342,7 @@ func teaserData(bucket, prefix string) layouts.Action {
 	}
 }
 
-func bigNewsData(bucket, prefix string) layouts.Action {
+func bigNewsData(data *content.Cache) layouts.Action {
 	return func(req *http.Request) (map[string]interface{}, error) {
 		// big news items are essentially fliers that link out to something important
 		type newsItem struct {
@@ -255,50 +351,52 @@ func bigNewsData(bucket, prefix string) layouts.Action {
 			Landscape                      bool // indicate if flier is landscape orientation
 			Expires                        time.Time
 		}
-		var bigNewsJson []byte
-		var bigNews []newsItem
-		var err error
-		bigNewsJson, err = readFromS3(bucket, prefix+"big-news.json")
-		if err != nil {
-			return nil, err
-		}
-		err = json.Unmarshal(bigNewsJson, &bigNews)
+		bigNews, err := data.Value("big-news.json", content.JSON([]newsItem(nil)))
 		if err != nil {
 			return nil, err
 		}
 		return map[string]interface{}{
-			"BigNews": bigNews,
+			"BigNews": bigNews.([]newsItem),
 			"ExtraJS": []string{"/js/big-news.js"},
 		}, nil
 	}
 }
 
-func headshotData(bucket, prefix string) layouts.Action {
+func headshotData(data *content.Cache) layouts.Action {
 	return func(req *http.Request) (map[string]interface{}, error) {
 		type headshot struct{ Name, Image, Looking, Plays string }
-		var headshots []headshot
-		var headshotJson []byte
-		var err error
-		headshotJson, err = readFromS3(bucket, prefix+"headshots.json")
+		headshots, err := data.Value("headshots.json", content.JSON([]headshot(nil)))
 		if err != nil {
 			return nil, err
 		}
-		err = json.Unmarshal(headshotJson, &headshots)
-		if err != nil {
-			return nil, err
+		band := jsonld.Band{
+			Name: "Run Boy Run",
+			URL:  absURL(req, "/"),
+			Logo: absURL(req, "/img/rbr-logo.png"),
+			SameAs: []string{
+				"http://twitter.com/runboyrunband",
+				"http://www.facebook.com/runboyrunband",
+				"http://www.youtube.com/runboyrunband",
+			},
+		}
+		for _, h := range headshots.([]headshot) {
+			band.Members = append(band.Members, jsonld.Member{
+				Name:     h.Name,
+				Image:    absURL(req, h.Image),
+				RoleName: h.Plays,
+			})
 		}
 		return map[string]interface{}{
-			"Headshots": headshots,
+			"Headshots": headshots.([]headshot),
+			"JSONLD":    []jsonld.Node{jsonld.MusicGroup(band)},
 		}, nil
 	}
 }
 
-func bioData(bucket, prefix string) layouts.Action {
+func bioData(data *content.Cache) layouts.Action {
 	return func(req *http.Request) (map[string]interface{}, error) {
-		var bio []byte
-		var err error
 		// read bio from markdown file
-		bio, err = readFromS3(bucket, prefix+"bio.md")
+		bio, err := data.Get("bio.md")
 		if err != nil {
 			return nil, err
 		}
@@ -308,30 +406,23 @@ func bioData(bucket, prefix string) layouts.Action {
 	}
 }
 
-func quoteData(bucket, prefix string) layouts.Action {
+func quoteData(data *content.Cache) layouts.Action {
 	return func(req *http.Request) (map[string]interface{}, error) {
 		type quote struct {
 			Quote       string
 			Attribution struct{ Name, URL, Affiliation string }
 		}
-		var quotes []quote
-		var quoteJson []byte
-		var err error
-		quoteJson, err = readFromS3(bucket, prefix+"quotes.json")
-		if err != nil {
-			return nil, err
-		}
-		err = json.Unmarshal(quoteJson, &quotes)
+		quotes, err := data.Value("quotes.json", content.JSON([]quote(nil)))
 		if err != nil {
 			return nil, err
 		}
 		return map[string]interface{}{
-			"Quotes": quotes,
+			"Quotes": quotes.([]quote),
 		}, nil
 	}
 }
 
-func musicData(bucket, prefix string) layouts.Action {
+func musicData(data *content.Cache) layouts.Action {
 	return func(req *http.Request) (map[string]interface{}, error) {
 		type quote struct {
 			Quote       string
@@ -346,40 +437,385 @@ func musicData(bucket, prefix string) layouts.Action {
 			BandcampID    string
 			Endorsement   []quote
 		}
-		albumJson, err := readFromS3(bucket, prefix+"albums.json")
+		albums, err := data.Value("albums.json", content.JSON([]Album(nil)))
 		if err != nil {
 			return nil, err
 		}
-		var albums []Album
-		err = json.Unmarshal(albumJson, &albums)
-		if err != nil {
-			return nil, err
+		ld := make([]jsonld.Node, 0, len(albums.([]Album)))
+		for _, a := range albums.([]Album) {
+			ld = append(ld, jsonld.MusicAlbum(jsonld.Album{
+				Name:          a.Name,
+				URL:           absURL(req, a.Url),
+				Image:         absURL(req, a.Image),
+				Description:   a.Description,
+				DatePublished: a.DatePublished,
+			}, "Run Boy Run"))
 		}
 		return map[string]interface{}{
-			"Albums": albums,
+			"Albums": albums.([]Album),
+			"JSONLD": ld,
 		}, nil
 	}
 }
 
-func showsData(artistID int, apiKey string) layouts.Action {
-	c := shows.New(artistID, apiKey)
+// Past shows listed on the main shows page
+const recentShows = 10
+
+// Past shows per page at /shows/past/
+const pastShowsPerPage = 25
+
+func showsData(gigs shows.Source, c *shows.Calendar) layouts.Action {
 	return func(req *http.Request) (map[string]interface{}, error) {
-		// Load Shows from API
-		past, err := c.Past(0)
-		if err != nil {
-			Error200(req, err)
+		// Load Shows from API, the rest of the past shows are at /shows/past/
+		upcoming, err := gigs.UpcomingContext(req.Context(), 0)
+		if err = degrade(req, err); err != nil {
+			return nil, err
+		}
+		near := nearQuery(req)
+		var nearby []shows.Event
+		if !near.Near.Zero() {
+			nearby = near.Filter(upcoming)
+		}
+		// optionally narrow down to, or group by, type of show
+		t, typeErr := shows.ParseEventType(req.FormValue("type"))
+		limit := recentShows
+		if typeErr == nil {
+			limit = 0 // the recent shows of a type could be anywhere in the gigography
+		}
+		past, err := gigs.PastContext(req.Context(), limit)
+		if err = degrade(req, err); err != nil {
+			return nil, err
+		}
+		var groups []shows.TypeGroup
+		if typeErr == nil {
+			upcoming, past = shows.FilterType(upcoming, t), shows.FilterType(past, t)
+			if len(past) > recentShows {
+				past = past[:recentShows]
+			}
+		} else if req.FormValue("group") == "type" {
+			groups = shows.GroupByType(past)
 		}
 		return map[string]interface{}{
 			"Events": struct {
-				Past []shows.Event
+				Upcoming  []shows.Event
+				Past      []shows.Event
+				Groups    []shows.TypeGroup
+				Near      shows.Query
+				Nearby    []shows.Event
+				Types     []shows.EventType
+				Type      string
+				Refreshed time.Time
 			}{
+				upcoming,
 				past,
+				groups,
+				near,
+				nearby,
+				shows.EventTypes,
+				req.FormValue("type"),
+				c.Refreshed(),
 			},
+			"JSONLD": jsonld.Events(upcoming),
 		}, nil
 	}
 }
 
-func contactData(bucket, prefix string) layouts.Action {
+// Decides what to do about an error loading shows. Temporary trouble (rate limits, timeouts,
+// a down API) is logged and the page goes on without the shows. Anything else, like a bad
+// API key, won't fix itself, so it is returned to fail the page.
+func degrade(req *http.Request, err error) error {
+	if err != nil && shows.Temporary(err) {
+		Error200(req, err)
+		return nil
+	}
+	return err
+}
+
+// A page of past shows, optionally for a single ?year=
+func pastData(gigs shows.Source) layouts.Action {
+	return func(req *http.Request) (map[string]interface{}, error) {
+		page, _ := strconv.Atoi(req.FormValue("page"))
+		if page < 1 {
+			page = 1
+		}
+		year, _ := strconv.Atoi(req.FormValue("year"))
+		// the whole gigography, for the festival summary and since a year could be anywhere in it
+		past, err := gigs.PastContext(req.Context(), 0)
+		if err != nil {
+			return nil, err
+		}
+		events := past
+		if year > 0 {
+			events = shows.Query{Year: year}.Filter(past)
+		}
+		start, end := (page-1)*pastShowsPerPage, page*pastShowsPerPage
+		if start >= len(events) && page > 1 {
+			return nil, ErrNotFound
+		}
+		more := len(events) > end
+		if end > len(events) {
+			end = len(events)
+		}
+		title := "Run Boy Run – Past Shows"
+		if year > 0 {
+			title += " " + strconv.Itoa(year)
+		}
+		if page > 1 {
+			title += ", page " + strconv.Itoa(page)
+		}
+		return map[string]interface{}{
+			"Title":  title,
+			"Events": events[start:end],
+			"Series": shows.GroupBySeries(past),
+			"Page": struct {
+				Year, PrevYear, NextYear int
+				Number, Prev, Next       int
+			}{
+				year,
+				func() int {
+					if year > 0 {
+						return year - 1
+					}
+					return 0
+				}(),
+				func() int {
+					if year > 0 && year < time.Now().Year() {
+						return year + 1
+					}
+					return 0
+				}(),
+				page,
+				page - 1,
+				func() int {
+					if more {
+						return page + 1
+					}
+					return 0
+				}(),
+			},
+		}, nil
+	}
+}
+
+// Builds a query from ?near=lat,lng&radius=km (and optionally from=2006-01-02&to=2006-01-02).
+// Anything missing or malformed is left out of the query.
+func nearQuery(req *http.Request) shows.Query {
+	const defaultRadius = 150 // km, about a couple hours drive
+	var q shows.Query
+	if g, err := shows.ParseGeoCoordinates(req.FormValue("near")); err == nil {
+		q.Near = g
+		q.Radius = defaultRadius
+	}
+	if r, err := strconv.ParseFloat(req.FormValue("radius"), 64); err == nil && r > 0 {
+		q.Radius = r
+	}
+	if t, err := time.Parse("2006-01-02", req.FormValue("from")); err == nil {
+		q.From = t
+	}
+	if t, err := time.Parse("2006-01-02", req.FormValue("to")); err == nil {
+		q.To = t.AddDate(0, 0, 1) // through the end of the day
+	}
+	return q
+}
+
+// Serves the list of shows at /shows/ and a page for each show at /shows/<id>/<slug>/,
+// along with its own iCalendar file at /shows/<id>/<slug>/event.ics.
+// Anything other than the canonical slug is redirected to it.
+func showPages(gigs shows.Source, list, detail http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/shows/" {
+			list.ServeHTTP(res, req)
+			return
+		}
+		id, file, ok := eventPath(req.URL.Path)
+		if !ok || (len(file) > 0 && file != "event.ics") {
+			Error404(res, req)
+			return
+		}
+		e, err := findEvent(req.Context(), gigs, id)
+		if err == ErrNotFound {
+			Error404(res, req)
+			return
+		} else if err != nil {
+			Error500(res, req, err)
+			return
+		}
+		if canonical := "/shows/" + e.ID + "/" + e.Slug() + "/" + file; req.URL.Path != canonical {
+			http.Redirect(res, req, canonical, http.StatusMovedPermanently)
+			return
+		}
+		if file == "event.ics" {
+			res.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+			res.Header().Set("Content-Disposition", "attachment; filename=\"run-boy-run-"+e.Slug()+".ics\"")
+			shows.ICalendar{
+				Name:   "Run Boy Run",
+				Domain: "runboyrunband.com",
+				Events: []shows.Event{e},
+			}.WriteTo(res)
+			return
+		}
+		detail.ServeHTTP(res, req.WithContext(context.WithValue(req.Context(), eventKey{}, e)))
+	})
+}
+
+// Request context key for the Event showPages found, for eventData
+type eventKey struct{}
+
+// Splits /shows/<id>/<slug>/<file> into the ID and file. The file may be empty.
+// The slug isn't checked here, since showPages redirects to the right one.
+func eventPath(p string) (id, file string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(p, "/shows/"), "/")
+	switch len(parts) {
+	case 2: // <id>/ or <id>/<slug>, missing its trailing slash
+		id = parts[0]
+		ok = true
+	case 3: // <id>/<slug>/<file>
+		id, file = parts[0], parts[2]
+		ok = len(parts[1]) > 0
+	}
+	return id, file, ok && len(id) > 0
+}
+
+// Looks through upcoming and past shows for the one with the given ID
+func findEvent(ctx context.Context, gigs shows.Source, id string) (shows.Event, error) {
+	upcoming, past, err := shows.AllFrom(ctx, gigs, 0)
+	if err != nil {
+		return shows.Event{}, err
+	}
+	for _, events := range [][]shows.Event{upcoming, past} {
+		for _, e := range events {
+			if e.ID == id {
+				return e, nil
+			}
+		}
+	}
+	return shows.Event{}, ErrNotFound
+}
+
+// A single show, at /shows/<id>/<slug>/, as found by showPages
+func eventData(req *http.Request) (map[string]interface{}, error) {
+	e, ok := req.Context().Value(eventKey{}).(shows.Event)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return map[string]interface{}{
+		"Title":  "Run Boy Run – " + e.Name,
+		"Event":  e,
+		"Over":   e.Over(time.Now()),
+		"JSONLD": jsonld.Events([]shows.Event{e}),
+	}, nil
+}
+
+// Statistics for every show we've played
+func historyData(gigs shows.Source) layouts.Action {
+	return func(req *http.Request) (map[string]interface{}, error) {
+		past, err := gigs.PastContext(req.Context(), 0)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"Stats": shows.ComputeStats(past, "Run Boy Run"),
+		}, nil
+	}
+}
+
+// Festival series we've played, or the history of a single series at /shows/festivals/<slug>/
+func festivalsData(gigs shows.Source) layouts.Action {
+	return func(req *http.Request) (map[string]interface{}, error) {
+		slug := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/shows/festivals/"), "/")
+		if strings.Contains(slug, "/") {
+			return nil, ErrNotFound
+		}
+		upcoming, past, err := shows.AllFrom(req.Context(), gigs, 0)
+		if err != nil {
+			return nil, err
+		}
+		series := shows.GroupBySeries(append(append([]shows.Event(nil), upcoming...), past...))
+		if len(slug) == 0 {
+			return map[string]interface{}{
+				"Title":  "Run Boy Run – Festivals",
+				"Series": series,
+			}, nil
+		}
+		for _, s := range series {
+			if s.Slug() == slug {
+				return map[string]interface{}{
+					"Title":    "Run Boy Run at " + s.Name,
+					"Festival": s,
+					"JSONLD":   jsonld.Events(s.Events),
+				}, nil
+			}
+		}
+		return nil, ErrNotFound
+	}
+}
+
+// Venues we've played, or every show at a single venue at /venues/<id>/
+func venuesData(gigs shows.Source) layouts.Action {
+	return func(req *http.Request) (map[string]interface{}, error) {
+		id := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/venues/"), "/")
+		if strings.Contains(id, "/") {
+			return nil, ErrNotFound
+		}
+		upcoming, past, err := shows.AllFrom(req.Context(), gigs, 0)
+		if err != nil {
+			return nil, err
+		}
+		venues := shows.GroupByVenue(append(append([]shows.Event(nil), upcoming...), past...))
+		if len(id) == 0 {
+			return map[string]interface{}{
+				"Title":  "Run Boy Run – Venues",
+				"Venues": venues,
+			}, nil
+		}
+		for _, v := range venues {
+			if v.Place.ID == id {
+				return map[string]interface{}{
+					"Title":  "Run Boy Run at " + v.Place.String(),
+					"Venue":  v,
+					"JSONLD": jsonld.Events(v.Events),
+				}, nil
+			}
+		}
+		return nil, ErrNotFound
+	}
+}
+
+// Serves past and upcoming shows as GeoJSON, for the tour map
+func geojsonHandler(gigs shows.Source) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		upcoming, past, err := shows.AllFrom(req.Context(), gigs, 0)
+		if err != nil {
+			Error500(res, req, err)
+			return
+		}
+		res.Header().Set("Content-Type", "application/geo+json")
+		res.Header().Set("Cache-Control", "public, max-age=3600")
+		if err := json.NewEncoder(res).Encode(shows.GeoJSON(append(append([]shows.Event(nil), upcoming...), past...))); err != nil {
+			Error200(req, err)
+		}
+	})
+}
+
+// Serves upcoming shows as an iCalendar feed
+func icalHandler(gigs shows.Source) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		upcoming, err := gigs.UpcomingContext(req.Context(), 0)
+		if err != nil {
+			Error500(res, req, err)
+			return
+		}
+		res.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		res.Header().Set("Cache-Control", "public, max-age=3600")
+		shows.ICalendar{
+			Name:   "Run Boy Run",
+			Domain: "runboyrunband.com",
+			Events: upcoming,
+		}.WriteTo(res)
+	})
+}
+
+func contactData(data *content.Cache) layouts.Action {
 	return func(req *http.Request) (map[string]interface{}, error) {
 		type contact struct {
 			Realm, Name, Email, Telephone string
@@ -388,41 +824,30 @@ func contactData(bucket, prefix string) layouts.Action {
 				Name, URL string
 			}
 		}
-		var contacts []struct {
+		type realm struct {
 			Realm   string
 			Contact []contact
 		}
-		if contactsJson, err := readFromS3(bucket, prefix+"contact.json"); err != nil {
+		contacts, err := data.Value("contact.json", content.JSON([]realm(nil)))
+		if err != nil {
 			return nil, err
-
-		} else {
-			err = json.Unmarshal(contactsJson, &contacts)
-			if err != nil {
-				return nil, err
-			}
 		}
 		return map[string]interface{}{
-			"Contacts": contacts,
+			"Contacts": contacts.([]realm),
 		}, nil
 	}
 }
 
-func photosData(bucket, prefix string) layouts.Action {
+func photosData(data *content.Cache) layouts.Action {
 	return func(req *http.Request) (map[string]interface{}, error) {
 		type photo struct{ Image, Copyright, Orientation, Composition string }
-		var photos []photo
-		if photosJson, err := readFromS3(bucket, prefix+"photos.json"); err != nil {
+		photos, err := data.Value("photos.json", content.JSON([]photo(nil)))
+		if err != nil {
 			return nil, err
-
-		} else {
-			err = json.Unmarshal(photosJson, &photos)
-			if err != nil {
-				return nil, err
-			}
 		}
 		// TODO: Move "Run Boy Run" out of titles into templates
 		return map[string]interface{}{
-			"Photos":  photos,
+			"Photos":  photos.([]photo),
 			"ExtraJS": []string{"/js/photos.js"},
 		}, nil
 	}
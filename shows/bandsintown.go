@@ -0,0 +1,164 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	bitBaseURL = "https://rest.bandsintown.com/artists/"
+)
+
+// Source of shows from the Bandsintown artist events API.
+// Responses can be cached the same way as a Calendar's, see SetRefreshInterval.
+type Bandsintown struct {
+	artist string
+	appID  string
+	client *http.Client
+	eventCache
+}
+
+// Create a new Bandsintown Source given the artist name and an app ID.
+// A nil client uses the same default as Calendar.
+func NewBandsintown(artist, appID string, client *http.Client) *Bandsintown {
+	if client == nil {
+		client = defaultClient
+	}
+	return &Bandsintown{
+		artist: artist,
+		appID:  appID,
+		client: client,
+	}
+}
+
+func (b *Bandsintown) UpcomingContext(ctx context.Context, limit int) ([]Event, error) {
+	return b.fromCache(ctx, "upcoming", limit, func(ctx context.Context, limit int) ([]Event, error) {
+		return b.get(ctx, "upcoming", limit, false)
+	})
+}
+
+func (b *Bandsintown) PastContext(ctx context.Context, limit int) ([]Event, error) {
+	return b.fromCache(ctx, "past", limit, func(ctx context.Context, limit int) ([]Event, error) {
+		return b.get(ctx, "past", limit, true)
+	})
+}
+
+func (b *Bandsintown) get(ctx context.Context, date string, limit int, past bool) ([]Event, error) {
+	u := bitBaseURL + url.PathEscape(b.artist) + "/events?date=" + date + "&app_id=" + url.QueryEscape(b.appID)
+	var bits []bitEvent
+	err := func() error {
+		t := time.Now()
+		defer func() {
+			log.Printf("\x1b[1;35mGet:\x1b[0m \x1b[34m%12d\x1b[0mµs \x1b[33m%s\x1b[0m", time.Since(t)/1000, redact(u))
+		}()
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := b.client.Do(req.WithContext(ctx))
+		if err != nil {
+			if ue, ok := err.(*url.Error); ok {
+				ue.URL = redact(ue.URL)
+			}
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return statusError(resp)
+		}
+		return json.NewDecoder(resp.Body).Decode(&bits)
+	}()
+	if err != nil {
+		// Bandsintown doesn't page, so everything is on page 1
+		return nil, &PageError{Endpoint: redact(u), Page: 1, Err: err}
+	}
+	events := make([]Event, 0, len(bits))
+	for _, bit := range bits {
+		events = append(events, bit.Event())
+	}
+	sortEvents(events, past)
+	return limitEvents(events, limit), nil
+}
+
+type bitEvent struct {
+	ID          string     `json:"id"`
+	URL         string     `json:"url"`
+	DateTime    string     `json:"datetime"` // local time at the venue, no offset
+	OnSale      string     `json:"on_sale_datetime"`
+	Description string     `json:"description"`
+	Title       string     `json:"title"`
+	Venue       bitVenue   `json:"venue"`
+	Lineup      []string   `json:"lineup"`
+	Offers      []bitOffer `json:"offers"`
+}
+
+type bitOffer struct {
+	Type   string `json:"type"` // Tickets|VIP
+	URL    string `json:"url"`
+	Status string `json:"status"` // available|sold out
+}
+
+type bitVenue struct {
+	Name      string `json:"name"`
+	Latitude  string `json:"latitude"`
+	Longitude string `json:"longitude"`
+	City      string `json:"city"`
+	Region    string `json:"region"`
+	Country   string `json:"country"`
+}
+
+func (bit bitEvent) Event() Event {
+	// local to the venue, but without an offset, so it reads as UTC until the time zone is known
+	start, _ := time.Parse("2006-01-02T15:04:05", bit.DateTime)
+	lat, _ := strconv.ParseFloat(bit.Venue.Latitude, 32)
+	lng, _ := strconv.ParseFloat(bit.Venue.Longitude, 32)
+	e := Event{
+		ID:          "bit-" + bit.ID,
+		Name:        bit.Title,
+		Description: bit.Description,
+		SameAs:      bit.URL,
+		URL:         []string{bit.URL},
+		StartDate:   start,
+		Location: Place{
+			Name: bit.Venue.Name,
+			Geo: GeoCoordinates{
+				Lat: float32(lat),
+				Lng: float32(lng),
+			},
+			Address: Address{
+				AddressCountry:  bit.Venue.Country,
+				AddressRegion:   bit.Venue.Region,
+				AddressLocality: bit.Venue.City,
+			},
+		},
+		Type: Concert,
+	}
+	if len(e.Name) == 0 {
+		e.Name = bit.Venue.Name
+	}
+	for _, name := range bit.Lineup {
+		e.Performer = append(e.Performer, MusicGroup{Name: name})
+	}
+	onSale, _ := time.Parse("2006-01-02T15:04:05", bit.OnSale)
+	for _, o := range bit.Offers {
+		availability := "InStock"
+		if o.Status == "sold out" {
+			availability = "SoldOut"
+		}
+		e.Offers = append(e.Offers, Offer{
+			URL:          o.URL,
+			Availability: availability,
+			ValidFrom:    onSale,
+		})
+	}
+	return e
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Keeps a copy of each list of events from an API, shared by Calendar and Bandsintown
+type eventCache struct {
+	refresh time.Duration
+	mu      sync.Mutex
+	entries map[string]*cachedEvents
+}
+
+type cachedEvents struct {
+	events     []Event
+	fetched    time.Time
+	refreshing bool
+}
+
+// Fetches events from an API, e.g. Calendar.get
+type fetchFunc func(ctx context.Context, limit int) ([]Event, error)
+
+// Keep a copy of each response from the API for d before asking again.
+//
+// Once the copy is older than d, it continues to be served while it is refreshed in the
+// background. If the refresh fails, the last good copy is kept. A zero interval (the
+// default) disables caching.
+func (ec *eventCache) SetRefreshInterval(d time.Duration) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.refresh = d
+}
+
+// Returns the time of the oldest cached response, i.e. all cached events are at least this
+// fresh. Returns the zero time if nothing is cached.
+func (ec *eventCache) Refreshed() time.Time {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	var t time.Time
+	for _, ce := range ec.entries {
+		if t.IsZero() || ce.fetched.Before(t) {
+			t = ce.fetched
+		}
+	}
+	return t
+}
+
+// Returns up to limit events for name from the cache when possible, falling back to get.
+//
+// The whole list is cached once per name and sliced for each limit, so the number of
+// entries stays fixed however callers page through it.
+func (ec *eventCache) fromCache(ctx context.Context, name string, limit int, get fetchFunc) ([]Event, error) {
+	ec.mu.Lock()
+	if ec.refresh <= 0 {
+		ec.mu.Unlock()
+		return get(ctx, limit)
+	}
+	if ce, ok := ec.entries[name]; ok {
+		if time.Since(ce.fetched) > ec.refresh && !ce.refreshing {
+			ce.refreshing = true
+			go ec.revalidate(name, get)
+		}
+		ec.mu.Unlock()
+		return limitEvents(ce.events, limit), nil
+	}
+	ec.mu.Unlock()
+
+	// nothing cached yet, so we have to wait
+	events, err := get(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	ec.mu.Lock()
+	if ec.entries == nil {
+		ec.entries = make(map[string]*cachedEvents)
+	}
+	ec.entries[name] = &cachedEvents{events: events, fetched: time.Now()}
+	ec.mu.Unlock()
+	return limitEvents(events, limit), nil
+}
+
+func (ec *eventCache) revalidate(name string, get fetchFunc) {
+	// not tied to any one request, so the client timeout has to do
+	events, err := get(context.Background(), 0)
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if err != nil {
+		// keep serving the last good copy
+		ec.entries[name].refreshing = false
+		log.Println("\x1b[1;31mStale:\x1b[0m", err)
+		return
+	}
+	ec.entries[name] = &cachedEvents{events: events, fetched: time.Now()}
+}
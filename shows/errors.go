@@ -0,0 +1,154 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Kinds of unsuccessful responses from an API, for use with errors.Is
+var (
+	ErrNotFound     = errors.New("shows: not found")
+	ErrUnauthorized = errors.New("shows: unauthorized")
+	ErrRateLimited  = errors.New("shows: rate limited")
+	ErrServer       = errors.New("shows: server error")
+)
+
+// Returned by a Calendar with no endpoint to fetch from
+var ErrNoEndpoint = errors.New("shows: no endpoint")
+
+// A failure to fetch a page of events, after any retries.
+// Err is often a *StatusError, but may be a network, context or JSON error.
+type PageError struct {
+	Endpoint string // with any API key left out
+	Page     int
+	Err      error
+}
+
+func (e *PageError) Error() string {
+	return "shows: " + e.Endpoint + " page " + strconv.Itoa(e.Page) + ": " + e.Err.Error()
+}
+
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// An unsuccessful response from the SongKick or Bandsintown API
+type StatusError struct {
+	StatusCode int
+	Status     string        // e.g. "503 Service Unavailable"
+	RetryAfter time.Duration // how long the API asked us to wait, if it did
+}
+
+func (e *StatusError) Error() string {
+	return "API error " + e.Status
+}
+
+// Returns the kind of error, e.g. ErrRateLimited, or nil if there isn't one
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// Indicates the same request might work if tried again later
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Indicates err is likely to go away on its own, e.g. a rate limit, server error or
+// timeout, so it's better to make do without the events for now than to fail.
+func Temporary(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Temporary()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+func statusError(r *http.Response) *StatusError {
+	return &StatusError{
+		StatusCode: r.StatusCode,
+		Status:     r.Status,
+		RetryAfter: retryAfter(r.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// Parses a Retry-After header, which is either a number of seconds or an HTTP date
+func retryAfter(v string, now time.Time) time.Duration {
+	if len(v) == 0 {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// Leaves API keys out of a URL, so it can be logged or shown in errors
+func redact(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	q := parsed.Query()
+	q.Del("apikey")
+	q.Del("app_id")
+	parsed.RawQuery = q.Encode()
+	return parsed.String()
+}
+
+// Retry policy for requests to the API
+const (
+	maxAttempts  = 4
+	maxRetryWait = 30 * time.Second // longer than this, we'd rather fail and serve stale shows
+)
+
+// First backoff, doubled for each attempt after. A variable so tests needn't wait.
+var retryBase = 500 * time.Millisecond
+
+// Returns how long to wait before trying again after err, or false if we shouldn't.
+// attempt counts from 0.
+func retryWait(err error, attempt int) (time.Duration, bool) {
+	if attempt+1 >= maxAttempts {
+		return 0, false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		if !se.Temporary() {
+			return 0, false
+		}
+		if se.RetryAfter > 0 {
+			return se.RetryAfter, se.RetryAfter <= maxRetryWait
+		}
+	}
+	// exponential backoff, with jitter so concurrent fetches don't retry in lockstep
+	backoff := retryBase << uint(attempt)
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2))), true
+}
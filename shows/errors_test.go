@@ -0,0 +1,56 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryWait(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		wait time.Duration
+		ok   bool
+	}{
+		{&StatusError{StatusCode: http.StatusUnauthorized}, 0, false},
+		{&StatusError{StatusCode: http.StatusNotFound}, 0, false},
+		{&StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second}, 2 * time.Second, true},
+		{&StatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Hour}, time.Hour, false},
+		{context.Canceled, 0, false},
+	} {
+		wait, ok := retryWait(c.err, 0)
+		if wait != c.wait || ok != c.ok {
+			t.Errorf("retryWait(%v) = %v, %v, want %v, %v", c.err, wait, ok, c.wait, c.ok)
+		}
+	}
+	if _, ok := retryWait(&StatusError{StatusCode: http.StatusBadGateway}, maxAttempts-1); ok {
+		t.Errorf("retried after %d attempts", maxAttempts)
+	}
+	for attempt := 0; attempt < maxAttempts-1; attempt++ {
+		backoff := retryBase << uint(attempt)
+		wait, ok := retryWait(&StatusError{StatusCode: http.StatusBadGateway}, attempt)
+		if !ok || wait < backoff/2 || wait >= backoff {
+			t.Errorf("attempt %d: waited %v, want between %v and %v", attempt, wait, backoff/2, backoff)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2013, time.August, 2, 12, 0, 0, 0, time.UTC)
+	for v, want := range map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-1":                            0,
+		"Fri, 02 Aug 2013 12:00:30 GMT": 30 * time.Second,
+		"Fri, 02 Aug 2013 11:00:00 GMT": 0,
+		"soon":                          0,
+	} {
+		if got := retryAfter(v, now); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
@@ -4,6 +4,10 @@
 package shows
 
 import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,9 +21,57 @@ const (
 	Club
 )
 
+// Every EventType, in the order they should be listed
+var EventTypes = []EventType{Festival, Concert, ListeningRoom, Club}
+
+var eventTypeNames = map[EventType]string{
+	Festival:      "festival",
+	Concert:       "concert",
+	ListeningRoom: "listening-room",
+	Club:          "club",
+}
+
+var eventTypePlurals = map[EventType]string{
+	Festival:      "Festivals",
+	Concert:       "Concerts",
+	ListeningRoom: "Listening Rooms",
+	Club:          "Clubs",
+}
+
+// Short name suitable for URLs and configuration, e.g. "listening-room"
+func (t EventType) String() string {
+	return eventTypeNames[t]
+}
+
+// Display name for a list of events of this type, e.g. "Listening Rooms"
+func (t EventType) Plural() string {
+	return eventTypePlurals[t]
+}
+
+// Parses the short name of an EventType, as returned by String
+func ParseEventType(s string) (EventType, error) {
+	for t, name := range eventTypeNames {
+		if name == s {
+			return t, nil
+		}
+	}
+	return 0, errors.New("shows: unknown event type " + s)
+}
+
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *EventType) UnmarshalText(text []byte) error {
+	var err error
+	*t, err = ParseEventType(string(text))
+	return err
+}
+
 // Events roughly match the schema.org event type
 // TODO: Update URL to a map instead of a slice
 type Event struct {
+	ID          string // stable identifier from the source, e.g. the SongKick event ID
 	Description string
 	Image       string
 	Name        string
@@ -28,8 +80,13 @@ type Event struct {
 	Duration    time.Duration
 	EndDate     time.Time
 	StartDate   time.Time
+	AllDay      bool   // only dates are known, StartDate and EndDate are midnight
+	TimeZone    string // IANA name of the venue's time zone, e.g. "America/New_York"
 	Location    Place
-	Performer   []MusicGroup
+	Performer   []MusicGroup // in billing order
+	Billing     Billing      // the artist's own billing
+	Series      string       // festival series, e.g. "MerleFest"
+	Offers      []Offer
 	Type        EventType
 }
 
@@ -37,6 +94,11 @@ func (e Event) Event() Event {
 	return e
 }
 
+// URL friendly version of the event name
+func (e Event) Slug() string {
+	return Slug(e.Name)
+}
+
 // Utility mainly useful for templates. Returns true if Event is a Festival
 func (e Event) Festival() bool {
 	return e.Type == Festival
@@ -47,13 +109,101 @@ func (e Event) Concert() bool {
 	return e.Type == Concert
 }
 
+// Utility mainly useful for templates. Returns true if Event is at a Listening Room
+func (e Event) ListeningRoom() bool {
+	return e.Type == ListeningRoom
+}
+
+// Utility mainly useful for templates. Returns true if Event is at a Club
+func (e Event) Club() bool {
+	return e.Type == Club
+}
+
+// Indicates the event has ended by the given time.
+// All day events last until the end of their last day.
+func (e Event) Over(now time.Time) bool {
+	end := e.EndDate
+	if end.IsZero() {
+		end = e.StartDate
+	}
+	if e.AllDay {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end.Before(now)
+}
+
+// Utility mainly useful for templates. Returns true if the artist is a support act
+func (e Event) Opening() bool {
+	return e.Billing == Support
+}
+
+// Performers with headline billing
+func (e Event) Headliners() []MusicGroup {
+	return e.billed(Headline)
+}
+
+// Performers with support billing
+func (e Event) Support() []MusicGroup {
+	return e.billed(Support)
+}
+
+func (e Event) billed(b Billing) []MusicGroup {
+	groups := make([]MusicGroup, 0, len(e.Performer))
+	for _, p := range e.Performer {
+		if p.Billing == b {
+			groups = append(groups, p)
+		}
+	}
+	return groups
+}
+
 // Indication that the artist is the only performer
 func (e Event) Solo() bool {
 	return len(e.Performer) == 1
 }
 
+// Ticket offers roughly match http://schema.org/Offer
+type Offer struct {
+	URL           string
+	LowPrice      float64
+	HighPrice     float64
+	PriceCurrency string    // ISO 4217, e.g. "USD"
+	Availability  string    // http://schema.org/ItemAvailability, e.g. "InStock" or "SoldOut"
+	ValidFrom     time.Time // when tickets go on sale
+}
+
+// Utility mainly useful for templates. Returns true if the offer has no tickets left
+func (o Offer) SoldOut() bool {
+	return o.Availability == "SoldOut"
+}
+
+// Utility mainly useful for templates. Returns true if tickets are on sale now
+func (o Offer) OnSale() bool {
+	return !o.SoldOut() && (o.ValidFrom.IsZero() || o.ValidFrom.Before(time.Now()))
+}
+
+// Price or price range for display, e.g. "$10" or "$10–$15". Empty if there is no price.
+func (o Offer) Price() string {
+	if o.LowPrice == 0 && o.HighPrice == 0 {
+		return ""
+	}
+	format := func(p float64) string {
+		s := strconv.FormatFloat(p, 'f', 2, 64)
+		s = strings.TrimSuffix(s, ".00")
+		if o.PriceCurrency == "USD" || len(o.PriceCurrency) == 0 {
+			return "$" + s
+		}
+		return s + " " + o.PriceCurrency
+	}
+	if o.HighPrice <= o.LowPrice {
+		return format(o.LowPrice)
+	}
+	return format(o.LowPrice) + "–" + format(o.HighPrice)
+}
+
 // Locations roughly match http://schema.org/Place
 type Place struct {
+	ID          string // stable identifier from the source, e.g. the SongKick venue ID
 	Description string
 	Image       string
 	Name        string
@@ -64,6 +214,17 @@ type Place struct {
 	Telephone   string
 }
 
+// Venue name and location, e.g. "The Tin Pan, Richmond, VA"
+func (p Place) String() string {
+	parts := make([]string, 0, 4)
+	for _, s := range []string{p.Name, p.Address.AddressLocality, p.Address.AddressRegion, p.Address.AddressCountry} {
+		if len(s) > 0 {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 // Roughly matches http://schema.org/PostalAddress
 type Address struct {
 	AddressCountry      string
@@ -80,13 +241,62 @@ type GeoCoordinates struct {
 	Elevation int
 }
 
+// Indicates no coordinates are known
+func (g GeoCoordinates) Zero() bool {
+	return g.Lat == 0 && g.Lng == 0
+}
+
+// Great-circle distance in km to another point, using the haversine formula
+func (g GeoCoordinates) Distance(o GeoCoordinates) float64 {
+	const earthRadius = 6371.0 // km
+	rad := func(deg float32) float64 { return float64(deg) * math.Pi / 180 }
+	dLat := rad(o.Lat - g.Lat)
+	dLng := rad(o.Lng - g.Lng)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(g.Lat))*math.Cos(rad(o.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// Used to distinguish headliners from support acts
+type Billing int
+
+const (
+	Headline Billing = iota
+	Support
+)
+
+func (b Billing) String() string {
+	if b == Support {
+		return "support"
+	}
+	return "headline"
+}
+
+func (b Billing) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *Billing) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "headline":
+		*b = Headline
+	case "support":
+		*b = Support
+	default:
+		return errors.New("shows: unknown billing " + string(text))
+	}
+	return nil
+}
+
 // roughly matches http://schema.org/MusicGroup
 type MusicGroup struct {
-	Description string
-	Image       string
-	Name        string
-	SameAs      string
-	URL         []string
+	Description  string
+	Image        string
+	Name         string
+	SameAs       string
+	URL          []string
+	Billing      Billing
+	BillingIndex int // position on the bill, starting at 1
 }
 
 type Eventer interface {
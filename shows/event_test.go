@@ -0,0 +1,30 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	var (
+		richmond  = GeoCoordinates{Lat: 37.5407, Lng: -77.4360}
+		nashville = GeoCoordinates{Lat: 36.1627, Lng: -86.7816}
+		london    = GeoCoordinates{Lat: 51.5074, Lng: -0.1278}
+	)
+	for _, c := range []struct {
+		a, b GeoCoordinates
+		km   float64
+	}{
+		{richmond, richmond, 0},
+		{richmond, nashville, 845},
+		{nashville, richmond, 845},
+		{richmond, london, 6061},
+	} {
+		if d := c.a.Distance(c.b); math.Abs(d-c.km) > c.km/100+0.01 {
+			t.Errorf("%v to %v is %.1fkm, want about %.0fkm", c.a, c.b, d, c.km)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Events from the gigography recorded in testdata
+func TestReplay(t *testing.T) {
+	cal := New(42, "any key", HTTPClient(&http.Client{Transport: Replay("testdata")}))
+	events, err := cal.Past(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+
+	fest := events[0]
+	if fest.ID != "3" || fest.Type != Festival || !fest.AllDay {
+		t.Errorf("got %s (%v, all day %v), want MerleFest as an all day festival", fest.ID, fest.Type, fest.AllDay)
+	}
+	if want := time.Date(2014, time.April, 27, 0, 0, 0, 0, time.UTC); !fest.EndDate.Equal(want) {
+		t.Errorf("MerleFest ends %v, want %v", fest.EndDate, want)
+	}
+
+	tinPan := events[1]
+	if want := time.Date(2013, time.November, 3, 0, 0, 0, 0, time.UTC); !tinPan.StartDate.Equal(want) || tinPan.AllDay {
+		t.Errorf("The Tin Pan starts %v (all day %v), want %v", tinPan.StartDate, tinPan.AllDay, want)
+	}
+	if a := tinPan.Location.Address; a.AddressLocality != "Richmond" || a.AddressRegion != "VA" {
+		t.Errorf("The Tin Pan is in %s, %s, want Richmond, VA", a.AddressLocality, a.AddressRegion)
+	}
+	if h, s := tinPan.Headliners(), tinPan.Support(); len(h) != 1 || len(s) != 1 || s[0].Name != "Other, Band" {
+		t.Errorf("got headliners %v and support %v", h, s)
+	}
+	if len(tinPan.Offers) != 0 {
+		t.Errorf("SongKick events shouldn't have offers, got %v", tinPan.Offers)
+	}
+
+	if _, err := cal.Upcoming(0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("without a recorded calendar got %v, want ErrNotFound", err)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	srv := httptest.NewServer(&fakeSongKick{total: 3, pages: make(map[int]int), sizes: make(map[int]bool)})
+	defer srv.Close()
+	dir := t.TempDir()
+	recorded := New(42, testKey, BaseURL(srv.URL+"/"), HTTPClient(&http.Client{Transport: Record(dir, nil)}))
+	want, err := recorded.Past(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("recorded %v, want one page", files)
+	}
+	if name := filepath.Base(files[0]); name != "42-gigography-json-order-desc-page-1-per-page-50.json" {
+		t.Errorf("recorded %s", name)
+	}
+	b, _ := ioutil.ReadFile(files[0])
+	if strings.Contains(string(b)+files[0], testKey) {
+		t.Errorf("recorded the API key")
+	}
+
+	// the server is gone, but the replay doesn't need it
+	srv.Close()
+	replayed := New(42, "", BaseURL(srv.URL+"/"), HTTPClient(&http.Client{Transport: Replay(dir)}))
+	got, err := replayed.Past(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("replayed %d events, recorded %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i].ID != want[i].ID || !got[i].StartDate.Equal(want[i].StartDate) {
+			t.Errorf("replayed %s on %v, recorded %s on %v", got[i].ID, got[i].StartDate, want[i].ID, want[i].StartDate)
+		}
+	}
+}
+
+func TestFixtureTransport(t *testing.T) {
+	if rt, err := FixtureTransport("", ""); rt != nil || err != nil {
+		t.Errorf("live mode got %v, %v, want the default transport", rt, err)
+	}
+	if _, err := FixtureTransport("replay", ""); err != ErrNoFixtures {
+		t.Errorf("replay without a directory got %v, want ErrNoFixtures", err)
+	}
+	if _, err := FixtureTransport("rewind", "testdata"); err == nil {
+		t.Errorf("unknown mode got no error")
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"time"
+)
+
+// Roughly matches a GeoJSON FeatureCollection (RFC 7946)
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Roughly matches a GeoJSON Feature with a Point geometry
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   Point                  `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Roughly matches a GeoJSON Point. Coordinates are longitude first.
+type Point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float32 `json:"coordinates"`
+}
+
+// Describes events as GeoJSON points at their venues, skipping events without coordinates
+func GeoJSON(events []Event) FeatureCollection {
+	fc := FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]Feature, 0, len(events)),
+	}
+	now := time.Now()
+	for _, e := range events {
+		g := e.Location.Geo
+		if g.Zero() {
+			continue
+		}
+		fc.Features = append(fc.Features, Feature{
+			Type: "Feature",
+			ID:   e.ID,
+			Geometry: Point{
+				Type:        "Point",
+				Coordinates: [2]float32{g.Lng, g.Lat},
+			},
+			Properties: map[string]interface{}{
+				"name":     e.Name,
+				"date":     e.StartDate.Format("2006-01-02"),
+				"venue":    e.Location.Name,
+				"locality": e.Location.Address.AddressLocality,
+				"region":   e.Location.Address.AddressRegion,
+				"country":  e.Location.Address.AddressCountry,
+				"type":     e.Type.String(),
+				"url":      e.SameAs,
+				"upcoming": !e.Over(now),
+			},
+		})
+	}
+	return fc
+}
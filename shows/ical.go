@@ -0,0 +1,137 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+	icalFloating = "20060102T150405" // local time wherever the event is
+)
+
+// An RFC 5545 calendar of Events, suitable for subscribing to from a calendar app
+type ICalendar struct {
+	Name   string // Display name for the calendar
+	Domain string // Used to build globally unique IDs from Event IDs
+	Events []Event
+}
+
+// Writes the calendar in iCalendar format
+func (cal ICalendar) WriteTo(w io.Writer) (int64, error) {
+	iw := &icalWriter{w: bufio.NewWriter(w)}
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:-//" + escapeText(cal.Domain) + "//Shows//EN")
+	iw.line("CALSCALE:GREGORIAN")
+	iw.line("METHOD:PUBLISH")
+	if len(cal.Name) > 0 {
+		iw.line("X-WR-CALNAME:" + escapeText(cal.Name))
+	}
+	stamp := time.Now().UTC().Format(icalDateTime)
+	for _, e := range cal.Events {
+		iw.line("BEGIN:VEVENT")
+		iw.line("UID:" + e.ID + "@" + cal.Domain)
+		iw.line("DTSTAMP:" + stamp)
+		if e.AllDay {
+			iw.line("DTSTART;VALUE=DATE:" + e.StartDate.Format(icalDate))
+			// end dates are exclusive for all day events
+			end := e.StartDate
+			if !e.EndDate.IsZero() {
+				end = e.EndDate
+			}
+			iw.line("DTEND;VALUE=DATE:" + end.AddDate(0, 0, 1).Format(icalDate))
+		} else {
+			iw.line("DTSTART:" + icalTime(e, e.StartDate))
+			if !e.EndDate.IsZero() {
+				iw.line("DTEND:" + icalTime(e, e.EndDate))
+			}
+		}
+		iw.line("SUMMARY:" + escapeText(e.Name))
+		if loc := e.Location.String(); len(loc) > 0 {
+			iw.line("LOCATION:" + escapeText(loc))
+		}
+		if g := e.Location.Geo; g.Lat != 0 || g.Lng != 0 {
+			iw.line("GEO:" + strconv.FormatFloat(float64(g.Lat), 'f', -1, 32) + ";" + strconv.FormatFloat(float64(g.Lng), 'f', -1, 32))
+		}
+		if len(e.Performer) > 1 {
+			lineup := make([]string, 0, 2)
+			if h := e.Headliners(); len(h) > 0 {
+				lineup = append(lineup, "Headlining: "+names(h))
+			}
+			if s := e.Support(); len(s) > 0 {
+				lineup = append(lineup, "Support: "+names(s))
+			}
+			iw.line("DESCRIPTION:" + escapeText(strings.Join(lineup, "\n")))
+		}
+		if len(e.SameAs) > 0 {
+			iw.line("URL:" + e.SameAs)
+		}
+		iw.line("END:VEVENT")
+	}
+	iw.line("END:VCALENDAR")
+	if iw.err == nil {
+		iw.err = iw.w.Flush()
+	}
+	return iw.n, iw.err
+}
+
+// Formats a time of e in UTC, unless it has no offset and e has no time zone. Then it is
+// only known as the venue's wall clock time, so it is left floating.
+func icalTime(e Event, t time.Time) string {
+	if len(e.TimeZone) == 0 && t.Location() == time.UTC {
+		return t.Format(icalFloating)
+	}
+	return t.UTC().Format(icalDateTime)
+}
+
+func names(groups []MusicGroup) string {
+	n := make([]string, 0, len(groups))
+	for _, g := range groups {
+		n = append(n, g.Name)
+	}
+	return strings.Join(n, ", ")
+}
+
+// Escapes TEXT values per RFC 5545 section 3.3.11
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// Writes content lines, folding them at 75 octets without splitting characters
+type icalWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (iw *icalWriter) line(s string) {
+	max := 75
+	for iw.err == nil {
+		if len(s) <= max {
+			iw.write(s + "\r\n")
+			return
+		}
+		i := max
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+		iw.write(s[:i] + "\r\n ")
+		s = s[i:]
+		max = 74 // leave room for the leading space
+	}
+}
+
+func (iw *icalWriter) write(s string) {
+	var n int
+	n, iw.err = iw.w.WriteString(s)
+	iw.n += int64(n)
+}
@@ -0,0 +1,78 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestICalFolding(t *testing.T) {
+	name := strings.Repeat("Run Boy Run – Bluegrass Ballads, Fiddles & Cellos; ", 5)
+	var buf bytes.Buffer
+	n, err := ICalendar{
+		Name:   "Run Boy Run",
+		Domain: "runboyrunband.com",
+		Events: []Event{{
+			ID:        "1",
+			Name:      name,
+			StartDate: time.Date(2013, time.August, 2, 20, 0, 0, 0, time.UTC),
+		}},
+	}.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo counted %d bytes, wrote %d", n, buf.Len())
+	}
+	out := buf.String()
+	if !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Errorf("calendar doesn't end with END:VCALENDAR and a CRLF")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line is %d octets: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a character: %q", line)
+		}
+	}
+	unfolded := strings.Replace(out, "\r\n ", "", -1)
+	if want := "SUMMARY:" + escapeText(name) + "\r\n"; !strings.Contains(unfolded, want) {
+		t.Errorf("unfolded calendar is missing %q", want)
+	}
+	if !strings.Contains(unfolded, "UID:1@runboyrunband.com\r\n") {
+		t.Errorf("unfolded calendar is missing the event UID")
+	}
+}
+
+func TestICalTimes(t *testing.T) {
+	wall := time.Date(2013, time.August, 2, 20, 0, 0, 0, time.UTC)
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, c := range []struct {
+		e    Event
+		want string
+	}{
+		// no time zone resolved, so the venue's wall clock
+		{Event{StartDate: wall}, "DTSTART:20130802T200000\r\n"},
+		{Event{StartDate: time.Date(2013, time.August, 2, 20, 0, 0, 0, newYork), TimeZone: "America/New_York"}, "DTSTART:20130803T000000Z\r\n"},
+		{Event{StartDate: time.Date(2013, time.August, 2, 20, 0, 0, 0, time.FixedZone("", -4*3600))}, "DTSTART:20130803T000000Z\r\n"},
+		{Event{StartDate: wall, AllDay: true}, "DTSTART;VALUE=DATE:20130802\r\n"},
+	} {
+		c.e.ID = "1"
+		var buf bytes.Buffer
+		if _, err := (ICalendar{Domain: "runboyrunband.com", Events: []Event{c.e}}).WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), c.want) {
+			t.Errorf("start %v in %q: missing %q", c.e.StartDate, c.e.TimeZone, c.want)
+		}
+	}
+}
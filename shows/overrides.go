@@ -0,0 +1,61 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"time"
+)
+
+// Manual corrections to a single Event. Empty fields leave the Event alone.
+type Override struct {
+	Hide      bool // keep the event off the site entirely
+	Name      string
+	Venue     string // replaces Location.Name
+	StartDate time.Time
+	EndDate   time.Time
+	URL       []string // added to the event's URLs
+	Offers    []Offer  // replaces any ticket offers from the source
+}
+
+// Overrides keyed by Event ID (the SongKick event ID for SongKick events)
+type Overrides map[string]Override
+
+// Returns a copy of events with overrides applied and hidden events removed
+func (o Overrides) Apply(events []Event) []Event {
+	patched := make([]Event, 0, len(events))
+	for _, e := range events {
+		ov, ok := o[e.ID]
+		if !ok {
+			patched = append(patched, e)
+			continue
+		}
+		if ov.Hide {
+			continue
+		}
+		if len(ov.Name) > 0 {
+			e.Name = ov.Name
+		}
+		if len(ov.Venue) > 0 {
+			e.Location.Name = ov.Venue
+		}
+		if !ov.StartDate.IsZero() {
+			e.StartDate = ov.StartDate
+		}
+		if !ov.EndDate.IsZero() {
+			e.EndDate = ov.EndDate
+		}
+		if !e.StartDate.IsZero() && !e.EndDate.IsZero() {
+			e.Duration = e.EndDate.Sub(e.StartDate)
+		}
+		if len(ov.Offers) > 0 {
+			e.Offers = ov.Offers
+		}
+		if len(ov.URL) > 0 {
+			// copy, so we don't append to a slice shared with a cache
+			e.URL = append(append([]string(nil), e.URL...), ov.URL...)
+		}
+		patched = append(patched, e)
+	}
+	return patched
+}
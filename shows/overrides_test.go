@@ -0,0 +1,42 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestOverrides(t *testing.T) {
+	var gigography []Event
+	for i := 1; i <= 60; i++ {
+		gigography = append(gigography, show(strconv.Itoa(i), 1+i%28, "Venue "+strconv.Itoa(i), 0, 0))
+	}
+	o := Overrides{
+		"3": {Hide: true},
+		"4": {Name: "Porch Party", Venue: "The Porch"},
+	}
+	s := With(staticSource{events: gigography}, func() (Applier, error) { return o, nil })
+
+	// hidden events don't count toward the limit
+	events, err := s.PastContext(context.Background(), 26)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 26 {
+		t.Fatalf("got %d events, want 26", len(events))
+	}
+	for _, e := range events {
+		if e.ID == "3" {
+			t.Errorf("hidden event 3 is listed")
+		}
+	}
+	if e := events[2]; e.ID != "4" || e.Name != "Porch Party" || e.Location.Name != "The Porch" {
+		t.Errorf("got %s %q at %q, want event 4 renamed", e.ID, e.Name, e.Location.Name)
+	}
+	if gigography[3].Name != "" {
+		t.Errorf("Apply changed the events it was given")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Narrows down a list of events by place and time. Zero fields match everything.
+type Query struct {
+	Near   GeoCoordinates // center of the search area
+	Radius float64        // km from Near
+	From   time.Time      // events ending on or after
+	To     time.Time      // events starting before
+	Year   int            // events starting in this year, by the date at the venue
+}
+
+// Indicates the event satisfies the query
+func (q Query) Match(e Event) bool {
+	if !q.Near.Zero() && q.Radius > 0 {
+		if e.Location.Geo.Zero() || q.Distance(e) > q.Radius {
+			return false
+		}
+	}
+	if !q.From.IsZero() && e.Over(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !e.StartDate.Before(q.To) {
+		return false
+	}
+	if q.Year > 0 && e.StartDate.Year() != q.Year {
+		return false
+	}
+	return true
+}
+
+// Returns only the events that satisfy the query, keeping their order
+func (q Query) Filter(events []Event) []Event {
+	filtered := make([]Event, 0, len(events))
+	for _, e := range events {
+		if q.Match(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// Distance in km from the center of the query to the event's venue
+func (q Query) Distance(e Event) float64 {
+	return q.Near.Distance(e.Location.Geo)
+}
+
+// Parses coordinates written as "lat,lng", e.g. "35.2271,-80.8431"
+func ParseGeoCoordinates(s string) (GeoCoordinates, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return GeoCoordinates{}, errors.New("shows: coordinates must be lat,lng")
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 32)
+	if err != nil {
+		return GeoCoordinates{}, err
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 32)
+	if err != nil {
+		return GeoCoordinates{}, err
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return GeoCoordinates{}, errors.New("shows: coordinates out of range")
+	}
+	return GeoCoordinates{Lat: float32(lat), Lng: float32(lng)}, nil
+}
@@ -0,0 +1,23 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryYear(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// already 2014 in UTC
+	nye := Event{ID: "nye", StartDate: time.Date(2013, time.December, 31, 21, 0, 0, 0, newYork), TimeZone: "America/New_York"}
+	for year, want := range map[int]bool{2013: true, 2014: false, 0: true} {
+		if got := (Query{Year: year}).Match(nye); got != want {
+			t.Errorf("year %d matched %v, want %v", year, got, want)
+		}
+	}
+}
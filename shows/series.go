@@ -0,0 +1,77 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Repeat appearances at a festival series, e.g. MerleFest
+type Series struct {
+	Name   string
+	Events []Event // most recent first
+}
+
+// Distinct years the artist played the series, in order
+func (s Series) Years() []int {
+	years := make([]int, 0, len(s.Events))
+	for i := len(s.Events) - 1; i >= 0; i-- {
+		y := s.Events[i].StartDate.Year()
+		if len(years) == 0 || years[len(years)-1] != y {
+			years = append(years, y)
+		}
+	}
+	return years
+}
+
+// URL friendly version of the series name
+func (s Series) Slug() string {
+	return Slug(s.Name)
+}
+
+// Groups events that are part of a series, leaving out everything else.
+// Series are ordered by name.
+func GroupBySeries(events []Event) []Series {
+	index := make(map[string]int)
+	series := make([]Series, 0)
+	for _, e := range events {
+		if len(e.Series) == 0 {
+			continue
+		}
+		i, ok := index[e.Series]
+		if !ok {
+			i = len(series)
+			index[e.Series] = i
+			series = append(series, Series{Name: e.Series})
+		}
+		series[i].Events = append(series[i].Events, e)
+	}
+	for _, s := range series {
+		sortEvents(s.Events, true)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return strings.ToLower(series[i].Name) < strings.ToLower(series[j].Name)
+	})
+	return series
+}
+
+// Makes a URL friendly version of a name, e.g. "Floyd Fest 2013!" becomes "floyd-fest-2013"
+func Slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
@@ -4,8 +4,10 @@
 package shows
 
 import (
-	"errors"
+	"context"
+	"net/http"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -13,25 +15,59 @@ const (
 	apiBaseURL = "http://api.songkick.com/api/3.0/artists/"
 )
 
+const (
+	maxPageSize  = 50 // most events SongKick will return at once
+	fetchWorkers = 4  // most pages to fetch at once
+)
+
+// Used when no other client is given
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
 // Represents the SongKick Artist Calendar.
 //
 // Uses both the calendar and gigography endpoints.
 type Calendar struct {
 	artistID int
 	apiKey   string
+	baseURL  string
+	client   *http.Client
+	eventCache
+}
+
+// Optional configuration for a Calendar
+type Option func(*Calendar)
+
+// Use the given client for all requests to the API, e.g. to set timeouts or transports
+func HTTPClient(client *http.Client) Option {
+	return func(c *Calendar) {
+		c.client = client
+	}
+}
+
+// Use a different API location, e.g. an httptest server.
+// The URL should end with the artists path, as in "http://api.songkick.com/api/3.0/artists/"
+func BaseURL(u string) Option {
+	return func(c *Calendar) {
+		c.baseURL = u
+	}
 }
 
 // Create a new Calendar given the SongKick ArtistID and an API Key
-func New(artistID int, apiKey string) *Calendar {
+func New(artistID int, apiKey string, opts ...Option) *Calendar {
 	c := new(Calendar)
-	c.Init(artistID, apiKey)
+	c.Init(artistID, apiKey, opts...)
 	return c
 }
 
 // Sets up a Calendar for a given artist
-func (c *Calendar) Init(artistID int, apiKey string) {
+func (c *Calendar) Init(artistID int, apiKey string, opts ...Option) {
 	c.artistID = artistID
 	c.apiKey = apiKey
+	c.baseURL = apiBaseURL
+	c.client = defaultClient
+	for _, opt := range opts {
+		opt(c)
+	}
 }
 
 type getResponse struct {
@@ -41,153 +77,120 @@ type getResponse struct {
 
 // Returns both Upcoming and Past Event slices with the given limit
 func (c *Calendar) All(limit int) ([]Event, []Event, error) {
-	var pevent, fevent []Event
-	past, future := make(chan getResponse), make(chan getResponse)
-	getem := func(fn func(int) ([]Event, error), ch chan getResponse) {
-		gr := getResponse{}
-		gr.Events, gr.Err = fn(limit)
-		ch <- gr
-	}
-
-	go getem(c.Past, past)
-	go getem(c.Upcoming, future)
-
-	for {
-		if pevent != nil && fevent != nil {
-			return fevent, pevent, nil
-		}
-		select {
-		case res := <-past:
-			if res.Err != nil {
-				return nil, nil, res.Err
-			}
-			pevent = res.Events
-		case res := <-future:
-			if res.Err != nil {
-				return nil, nil, res.Err
-			}
-			fevent = res.Events
-		case <-time.After(30 * time.Second):
-			return nil, nil, errors.New("API Request Timeout")
-		}
-	}
+	return c.AllContext(context.Background(), limit)
+}
 
-	return fevent, pevent, nil
+// Like All, but gives up when ctx is done
+func (c *Calendar) AllContext(ctx context.Context, limit int) ([]Event, []Event, error) {
+	return AllFrom(ctx, c, limit)
 }
 
-// Returns a slice of Events from the SongKick calendar endpoint
+// Returns a slice of Events from the SongKick calendar endpoint.
+// The slice may be shared with other callers, so it should not be modified.
 func (c *Calendar) Upcoming(limit int) ([]Event, error) {
-	return c.get(apiBaseURL+strconv.FormatInt(int64(c.artistID), 10)+"/calendar.json?order=asc&apikey="+c.apiKey, limit)
+	return c.UpcomingContext(context.Background(), limit)
+}
+
+// Like Upcoming, but gives up when ctx is done
+func (c *Calendar) UpcomingContext(ctx context.Context, limit int) ([]Event, error) {
+	return c.cached(ctx, c.endpoint("calendar.json", "asc"), limit)
 }
 
-// Returns a slice of Events from the SongKick gigography endpoint
+// Returns a slice of Events from the SongKick gigography endpoint.
+// The slice may be shared with other callers, so it should not be modified.
 func (c *Calendar) Past(limit int) ([]Event, error) {
-	return c.get(apiBaseURL+strconv.FormatInt(int64(c.artistID), 10)+"/gigography.json?order=desc&apikey="+c.apiKey, limit)
+	return c.PastContext(context.Background(), limit)
 }
 
-func (c *Calendar) get(url string, limit int) ([]Event, error) {
-	const (
-		maxPageSize = 50
-		chunkSize   = 5 // number of events to process at once
-	)
+// Like Past, but gives up when ctx is done
+func (c *Calendar) PastContext(ctx context.Context, limit int) ([]Event, error) {
+	return c.cached(ctx, c.endpoint("gigography.json", "desc"), limit)
+}
 
+// Returns events from url, through the cache
+func (c *Calendar) cached(ctx context.Context, url string, limit int) ([]Event, error) {
+	return c.fromCache(ctx, url, limit, func(ctx context.Context, limit int) ([]Event, error) {
+		return c.get(ctx, url, limit)
+	})
+}
+
+func (c *Calendar) endpoint(name, order string) string {
+	return c.baseURL + strconv.FormatInt(int64(c.artistID), 10) + "/" + name + "?order=" + order + "&apikey=" + c.apiKey
+}
+
+// Fetches up to limit events from url (all of them if limit is 0).
+//
+// The first page tells us how many events there are, then the rest of the pages are
+// fetched concurrently, by at most fetchWorkers at a time. Events stay in page order.
+func (c *Calendar) get(ctx context.Context, url string, limit int) ([]Event, error) {
+	perPage := maxPageSize
+	if limit > 0 && limit < perPage {
+		perPage = limit
+	}
+	first, err := getSkArtistCalendar(ctx, c.client, url, perPage)
+	if err != nil {
+		return nil, err
+	}
+	if first.PerPage > 0 {
+		perPage = first.PerPage // SongKick may not give us as many as we asked for
+	}
+	first.PerPage = perPage
+	want := first.TotalEntries
+	if limit > 0 && limit < want {
+		want = limit
+	}
+	pages := make([]*skArtistCalendar, (want+perPage-1)/perPage)
+	if len(pages) > 0 {
+		pages[0] = first
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops the rest of the workers after an error
 	var (
-		err  error
-		next chan skArtistCalendar
+		wg   sync.WaitGroup
+		once sync.Once
 	)
-	// we want the arrays under these slices to be allocated once and only once if we can,
-	// so we set the capacity to the limit
-	events := make([]Event, 0, limit)
-	queue := make([]skEvent, 0, limit)
-
-	stop := make(chan chan bool) // channel we can use to tell our worker to stop
-	stopped := make(chan bool)
-	receive := make(chan skResponse)
-	fetch := make(chan skArtistCalendar)
-
-	ac := new(skArtistCalendar)
-	ac.Endpoint = url
-
-	// Worker
-	go func(in chan skArtistCalendar, out chan skResponse, stop chan chan bool) {
-		var err error
-		for {
-			tmp := new(skArtistCalendar)
-			select {
-			case *tmp = <-in:
-				tmp, err = tmp.next()
-				out <- skResponse{tmp, err}
-			case stopped := <-stop:
-				stopped <- true
-				return
+	todo := make(chan int)
+	for w := 0; w < fetchWorkers && w < len(pages)-1; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range todo {
+				ac, e := first.page(ctx, c.client, i+1)
+				if e != nil {
+					once.Do(func() {
+						err = e
+						cancel()
+					})
+					continue
+				}
+				pages[i] = ac
 			}
+		}()
+	}
+	for i := 1; i < len(pages); i++ {
+		select {
+		case todo <- i:
+		case <-ctx.Done():
 		}
-	}(fetch, receive, stop)
-
-	// initial fetch
-	fetch <- *ac
-
-	// local loop
-	for {
-		var (
-			failure    chan chan bool
-			success    chan chan bool
-			queueReady chan bool
-		)
-
-		if err != nil {
-			// exit with error
-			failure = stop
-		} else if ac.Page > 0 && limit == len(events) {
-			// exit with success
-			success = stop
-		} else if len(queue) > 0 {
-			queueReady = make(chan bool)
-			go func() { queueReady <- true }()
-		}
+	}
+	close(todo)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if e := ctx.Err(); e != nil {
+		return nil, e
+	}
 
-		select {
-		case failure <- stopped: // failure
-			<-stopped
-			return nil, err
-		case success <- stopped: // success
-			<-stopped
-			return events, nil
-		case next <- *ac: // start fetch
-			next = nil // block additional fetches
-		case res := <-receive: // end fetch
-			err = res.Err
-			if err != nil {
+	events := make([]Event, 0, want)
+	for _, ac := range pages {
+		for _, e := range ac.Events {
+			if len(events) == want {
 				break
 			}
-			ac = res.Cal
-			if len(ac.Events) > 0 {
-				queue = append(queue, ac.Events...)
-			}
-			if limit == 0 || limit > ac.TotalEntries {
-				limit = ac.TotalEntries
-			}
-			if len(queue)+len(events) < limit {
-				next = fetch
-			}
-		case <-queueReady:
-			// try to drain the queue one chunk at a time
-			s := chunkSize
-			if s > limit-len(events) {
-				s = limit - len(events)
-			}
-			if s > len(queue) {
-				s = len(queue)
-			}
-			mini := queue[:s]
-			queue = queue[s:]
-			for _, e := range mini {
-				events = append(events, e.Event())
-			}
-		case <-time.After(30 * time.Second):
-			err = errors.New("API Timeout")
+			events = append(events, e.eventFor(c.artistID))
 		}
-
 	}
-	panic("get() did not exit correctly")
+	return events, nil
 }
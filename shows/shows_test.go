@@ -0,0 +1,307 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testKey = "s3cret"
+
+// A pretend SongKick gigography with total events, numbered from 1, most recent first
+type fakeSongKick struct {
+	total int
+	// Optionally replaces the response to a request for page, on its nth attempt (from 0).
+	// Returning 0 serves the page as usual.
+	status func(w http.ResponseWriter, r *http.Request, page, attempt int) int
+
+	mu    sync.Mutex
+	pages map[int]int // requests by page
+	sizes map[int]bool
+}
+
+func (f *fakeSongKick) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	f.mu.Lock()
+	attempt := f.pages[page]
+	f.pages[page]++
+	f.sizes[perPage] = true
+	f.mu.Unlock()
+
+	if q.Get("apikey") != testKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if f.status != nil {
+		if code := f.status(w, r, page, attempt); code != 0 {
+			w.WriteHeader(code)
+			return
+		}
+	}
+	type event struct {
+		ID    int `json:"id"`
+		Start struct {
+			Date string `json:"date"`
+		} `json:"start"`
+	}
+	var events []event
+	day := time.Date(2013, time.December, 31, 0, 0, 0, 0, time.UTC)
+	for id := (page-1)*perPage + 1; id <= page*perPage && id <= f.total; id++ {
+		e := event{ID: id}
+		e.Start.Date = day.AddDate(0, 0, -id).Format("2006-01-02")
+		events = append(events, e)
+	}
+	var resp struct {
+		ResultsPage struct {
+			Results struct {
+				Event []event `json:"event"`
+			} `json:"results"`
+			TotalEntries int `json:"totalEntries"`
+			PerPage      int `json:"perPage"`
+			Page         int `json:"page"`
+		} `json:"resultsPage"`
+	}
+	resp.ResultsPage.Results.Event = events
+	resp.ResultsPage.TotalEntries = f.total
+	resp.ResultsPage.PerPage = perPage
+	resp.ResultsPage.Page = page
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeSongKick) requests(page int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pages[page]
+}
+
+// Stands in for a slow response, until the client hangs up
+func wait(r *http.Request, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-r.Context().Done():
+	}
+}
+
+// Starts f and returns a Calendar using it. Retries are made quick for the test.
+func testCalendar(t *testing.T, f *fakeSongKick) *Calendar {
+	f.pages = make(map[int]int)
+	f.sizes = make(map[int]bool)
+	srv := httptest.NewServer(f)
+	base := retryBase
+	retryBase = time.Millisecond
+	t.Cleanup(func() {
+		srv.Close()
+		retryBase = base
+	})
+	return New(42, testKey, BaseURL(srv.URL+"/"))
+}
+
+func checkOrder(t *testing.T, events []Event, want int) {
+	t.Helper()
+	if len(events) != want {
+		t.Fatalf("got %d events, want %d", len(events), want)
+	}
+	for i, e := range events {
+		if e.ID != strconv.Itoa(i+1) {
+			t.Fatalf("event %d has ID %s, want %d", i, e.ID, i+1)
+		}
+	}
+}
+
+func TestCalendarPages(t *testing.T) {
+	f := &fakeSongKick{total: 273}
+	cal := testCalendar(t, f)
+	events, err := cal.Past(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOrder(t, events, 273)
+	for page := 1; page <= 6; page++ {
+		if n := f.requests(page); n != 1 {
+			t.Errorf("page %d requested %d times, want 1", page, n)
+		}
+	}
+	if n := f.requests(7); n != 0 {
+		t.Errorf("page 7 requested %d times, want 0", n)
+	}
+}
+
+func TestCalendarLimit(t *testing.T) {
+	for _, c := range []struct {
+		limit, want, pages, perPage int
+	}{
+		{10, 10, 1, 10},
+		{50, 50, 1, 50},
+		{70, 70, 2, 50},
+		{500, 120, 3, 50},
+	} {
+		f := &fakeSongKick{total: 120}
+		cal := testCalendar(t, f)
+		events, err := cal.Past(c.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkOrder(t, events, c.want)
+		if n := f.requests(c.pages + 1); n != 0 {
+			t.Errorf("limit %d: page %d requested, want only %d pages", c.limit, c.pages+1, c.pages)
+		}
+		if !f.sizes[c.perPage] || len(f.sizes) != 1 {
+			t.Errorf("limit %d: page sizes %v, want %d", c.limit, f.sizes, c.perPage)
+		}
+	}
+}
+
+func TestCalendarEmpty(t *testing.T) {
+	cal := testCalendar(t, &fakeSongKick{})
+	events, err := cal.Upcoming(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("got %d events, want none", len(events))
+	}
+}
+
+func TestCalendarRetry(t *testing.T) {
+	f := &fakeSongKick{total: 120, status: func(w http.ResponseWriter, r *http.Request, page, attempt int) int {
+		if page == 2 && attempt < 2 {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	}}
+	cal := testCalendar(t, f)
+	events, err := cal.Past(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOrder(t, events, 120)
+	if n := f.requests(2); n != 3 {
+		t.Errorf("page 2 requested %d times, want 3", n)
+	}
+}
+
+func TestCalendarRetryAfter(t *testing.T) {
+	f := &fakeSongKick{total: 10, status: func(w http.ResponseWriter, r *http.Request, page, attempt int) int {
+		if attempt == 0 {
+			w.Header().Set("Retry-After", "1")
+			return http.StatusTooManyRequests
+		}
+		return 0
+	}}
+	cal := testCalendar(t, f)
+	start := time.Now()
+	if _, err := cal.Past(0); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < time.Second {
+		t.Errorf("retried after %v, want at least the 1s asked for", d)
+	}
+}
+
+func TestCalendarGivesUp(t *testing.T) {
+	f := &fakeSongKick{total: 10, status: func(w http.ResponseWriter, r *http.Request, page, attempt int) int {
+		return http.StatusInternalServerError
+	}}
+	cal := testCalendar(t, f)
+	_, err := cal.Past(0)
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("got %v, want ErrServer", err)
+	}
+	if !Temporary(err) {
+		t.Errorf("%v should be temporary", err)
+	}
+	if n := f.requests(1); n != maxAttempts {
+		t.Errorf("page 1 requested %d times, want %d", n, maxAttempts)
+	}
+	if strings.Contains(err.Error(), testKey) {
+		t.Errorf("error %q shows the API key", err)
+	}
+}
+
+// An error on one page stops the fetches still waiting on others
+func TestCalendarCancel(t *testing.T) {
+	f := &fakeSongKick{total: 300, status: func(w http.ResponseWriter, r *http.Request, page, attempt int) int {
+		switch page {
+		case 1:
+			return 0
+		case 2:
+			return http.StatusUnauthorized
+		}
+		wait(r, 10*time.Second) // unless the Calendar gives up first
+		return 0
+	}}
+	cal := testCalendar(t, f)
+	start := time.Now()
+	_, err := cal.Past(0)
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("took %v to give up", d)
+	}
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("got %v, want ErrUnauthorized", err)
+	}
+	var pe *PageError
+	if !errors.As(err, &pe) || pe.Page != 2 {
+		t.Errorf("got %#v, want a PageError for page 2", err)
+	}
+	if Temporary(err) {
+		t.Errorf("%v shouldn't be temporary", err)
+	}
+	if n := f.requests(2); n != 1 {
+		t.Errorf("page 2 requested %d times, want no retries", n)
+	}
+}
+
+func TestCalendarContext(t *testing.T) {
+	f := &fakeSongKick{total: 10, status: func(w http.ResponseWriter, r *http.Request, page, attempt int) int {
+		wait(r, time.Second)
+		return 0
+	}}
+	cal := testCalendar(t, f)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := cal.PastContext(ctx, 0)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if n := f.requests(1); n != 1 {
+		t.Errorf("page 1 requested %d times, want no retries", n)
+	}
+}
+
+// Pages of past shows share one cached copy of the gigography
+func TestCalendarCache(t *testing.T) {
+	f := &fakeSongKick{total: 120}
+	cal := testCalendar(t, f)
+	cal.SetRefreshInterval(time.Hour)
+	for _, limit := range []int{10, 0, 30, 500, 10} {
+		events, err := cal.Past(limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := limit
+		if want == 0 || want > f.total {
+			want = f.total
+		}
+		checkOrder(t, events, want)
+	}
+	for page := 1; page <= 3; page++ {
+		if n := f.requests(page); n != 1 {
+			t.Errorf("page %d requested %d times, want 1", page, n)
+		}
+	}
+	if len(cal.entries) != 1 {
+		t.Errorf("%d cache entries, want 1", len(cal.entries))
+	}
+}
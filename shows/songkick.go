@@ -5,11 +5,15 @@ package shows
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	neturl "net/url"
+	"sort"
+	"strconv"
 	"time"
 )
 
@@ -31,18 +35,27 @@ type skEvent struct {
 
 func (ske skEvent) Event() Event {
 	e := Event{
+		ID:        strconv.Itoa(ske.ID),
 		Name:      ske.DisplayName,
+		Series:    ske.Series.DisplayName,
 		SameAs:    ske.URI,
 		URL:       []string{ske.URI},
-		StartDate: time.Time(ske.Start),
-		EndDate:   time.Time(ske.End),
+		StartDate: ske.Start.Time,
+		EndDate:   ske.End.Time,
+		AllDay:    ske.Start.dateOnly,
 		Duration: func(start, end time.Time) time.Duration {
 			if !start.IsZero() && !end.IsZero() {
 				return end.Sub(start)
 			}
 			return 0
-		}(time.Time(ske.Start), time.Time(ske.End)),
+		}(ske.Start.Time, ske.End.Time),
 		Location: Place{
+			ID: func(id int) string {
+				if id == 0 {
+					return "" // SongKick doesn't know the venue
+				}
+				return strconv.Itoa(id)
+			}(ske.Venue.ID),
 			Name:   ske.Venue.DisplayName,
 			SameAs: ske.Venue.URI,
 			URL:    []string{ske.Venue.URI},
@@ -60,11 +73,14 @@ func (ske skEvent) Event() Event {
 			p := make([]MusicGroup, 0)
 			for _, i := range skp {
 				p = append(p, MusicGroup{
-					Name:   i.Artist.DisplayName,
-					SameAs: i.Artist.URI,
-					URL:    []string{i.Artist.URI},
+					Name:         i.Artist.DisplayName,
+					SameAs:       i.Artist.URI,
+					URL:          []string{i.Artist.URI},
+					Billing:      i.billing(),
+					BillingIndex: i.BillingIndex,
 				})
 			}
+			sort.SliceStable(p, func(i, j int) bool { return p[i].BillingIndex < p[j].BillingIndex })
 			return p
 		}(ske.Performance),
 		Type: func(t string) EventType {
@@ -81,6 +97,17 @@ func (ske skEvent) Event() Event {
 	return e
 }
 
+// Like Event, but also notes the billing of the given artist
+func (ske skEvent) eventFor(artistID int) Event {
+	e := ske.Event()
+	for _, p := range ske.Performance {
+		if p.Artist.ID == artistID {
+			e.Billing = p.billing()
+		}
+	}
+	return e
+}
+
 type skPerformance struct {
 	ID           int      `json:"id"`
 	Artist       skArtist `json:"artist"`
@@ -89,6 +116,13 @@ type skPerformance struct {
 	Billing      string   `json:"billing"` // headline|support
 }
 
+func (skp skPerformance) billing() Billing {
+	if skp.Billing == "support" {
+		return Support
+	}
+	return Headline
+}
+
 type skArtist struct {
 	ID          int            `json:"id"`
 	URI         string         `json:"uri"`
@@ -128,7 +162,11 @@ type skMetroArea struct {
 	} `json:"state"`
 }
 
-type skDate time.Time
+// A date, with a time if SongKick knows it
+type skDate struct {
+	time.Time
+	dateOnly bool // no time was given, so Time is midnight UTC
+}
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
 // Time is expected in RFC3339 format.
@@ -142,14 +180,14 @@ func (d *skDate) UnmarshalJSON(data []byte) error {
 		if t, err := time.Parse("2006-01-02T15:04:05-0700", raw.DateTime); err != nil {
 			return err
 		} else {
-			*d = skDate(t)
+			*d = skDate{Time: t}
 			return nil
 		}
 	} else if len(raw.Date) > 0 {
 		if t, err := time.Parse("2006-01-02", raw.Date); err != nil {
 			return err
 		} else {
-			*d = skDate(t)
+			*d = skDate{Time: t, dateOnly: true}
 			return nil
 		}
 	}
@@ -186,69 +224,74 @@ func (ac *skArtistCalendar) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// returns the count of events polled so far
-func (ac skArtistCalendar) count() int {
-	count := ac.PerPage * ac.Page
-	if count > ac.TotalEntries {
-		count = ac.TotalEntries
-	}
-	return count
+// Fetches the first page of url with up to perPage events, which also tells us TotalEntries
+func getSkArtistCalendar(ctx context.Context, client *http.Client, url string, perPage int) (*skArtistCalendar, error) {
+	return skArtistCalendar{Endpoint: url, PerPage: perPage}.page(ctx, client, 1)
 }
 
-func getSkArtistCalendar(url string) (*skArtistCalendar, error) {
-	// this is a zero calendar, no url and no pages
-	return (&skArtistCalendar{Endpoint: url}).next()
-}
-
-const (
-	ErrorNoEndpoint   = "skArtistCalendar must have a URL Endpoint"
-	ErrorNoMoreEvents = "skArtistCalendar has retrieved all events"
-)
-
-func (ac skArtistCalendar) next() (*skArtistCalendar, error) {
+// Fetches page n of ac.Endpoint, using the same page size as ac
+func (ac skArtistCalendar) page(ctx context.Context, client *http.Client, n int) (*skArtistCalendar, error) {
 	if len(ac.Endpoint) < 1 {
-		return nil, errors.New(ErrorNoEndpoint)
-	}
-	if ac.count() == ac.TotalEntries && ac.Page > 0 { // not really an error...
-		return nil, errors.New(ErrorNoMoreEvents)
+		return nil, ErrNoEndpoint
 	}
-	ac.Page++ // next page
-
+	ac.Page = n
 	url := ac.Endpoint
 	if ac.PerPage > 0 {
 		url += fmt.Sprintf("&page=%d&per_page=%d", ac.Page, ac.PerPage)
 	}
-	// just so we can have a nice timer
-	resp, err := func() (*http.Response, error) {
-		t := time.Now()
-		defer func() {
-			log.Printf("\x1b[1;35mGet:\x1b[0m \x1b[34m%12d\x1b[0mµs \x1b[33m%s\x1b[0m", time.Since(t)/1000, url)
-		}()
-		r, e := http.Get(url)
-		if e != nil {
-			return nil, e
-		} else if r.StatusCode != http.StatusOK {
-			return nil, errors.New("API Error:" + r.Status)
+	var (
+		body []byte
+		err  error
+	)
+	for attempt := 0; ; attempt++ {
+		if body, err = fetch(ctx, client, url); err == nil {
+			break
+		}
+		wait, ok := retryWait(err, attempt)
+		if !ok {
+			return nil, &PageError{Endpoint: redact(ac.Endpoint), Page: n, Err: err}
+		}
+		log.Printf("\x1b[1;33mRetry:\x1b[0m \x1b[34m%12s\x1b[0m \x1b[33m%s\x1b[0m %v", wait, redact(url), err)
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, &PageError{Endpoint: redact(ac.Endpoint), Page: n, Err: ctx.Err()}
 		}
-		return r, nil
+	}
+
+	if err := json.Unmarshal(body, &ac); err != nil {
+		return nil, &PageError{Endpoint: redact(ac.Endpoint), Page: n, Err: err}
+	}
+	return &ac, nil
+}
+
+// Gets the body of a single response, or a *StatusError if the response isn't OK
+func fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	// just so we can have a nice timer
+	t := time.Now()
+	defer func() {
+		log.Printf("\x1b[1;35mGet:\x1b[0m \x1b[34m%12d\x1b[0mµs \x1b[33m%s\x1b[0m", time.Since(t)/1000, redact(url))
 	}()
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
-
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(resp.Body); err != nil {
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		if ue, ok := err.(*neturl.Error); ok {
+			ue.URL = redact(ue.URL)
+		}
 		return nil, err
 	}
-
-	if err := json.Unmarshal(buf.Bytes(), &ac); err != nil {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
 		return nil, err
 	}
-	return &ac, nil
-}
-
-// utility type for async access
-type skResponse struct {
-	Cal *skArtistCalendar
-	Err error
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,251 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Venues closer together than this (in km) on the same day are taken to be the same show
+const MergeRadius = 1.0
+
+// A Source provides upcoming shows in ascending order and past shows in descending order.
+// A limit of 0 means no limit.
+type Source interface {
+	UpcomingContext(ctx context.Context, limit int) ([]Event, error)
+	PastContext(ctx context.Context, limit int) ([]Event, error)
+}
+
+// Returns both Upcoming and Past Event slices from a Source with the given limit
+func AllFrom(ctx context.Context, s Source, limit int) ([]Event, []Event, error) {
+	// if either fails, there is no sense in waiting for the other
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var pevent, fevent []Event
+	past, future := make(chan getResponse, 1), make(chan getResponse, 1)
+	getem := func(fn func(context.Context, int) ([]Event, error), ch chan getResponse) {
+		gr := getResponse{}
+		gr.Events, gr.Err = fn(ctx, limit)
+		ch <- gr
+	}
+
+	go getem(s.PastContext, past)
+	go getem(s.UpcomingContext, future)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case res := <-past:
+			if res.Err != nil {
+				return nil, nil, res.Err
+			}
+			pevent = res.Events
+		case res := <-future:
+			if res.Err != nil {
+				return nil, nil, res.Err
+			}
+			fevent = res.Events
+		}
+	}
+	return fevent, pevent, nil
+}
+
+// Source of manually curated shows, e.g. private gigs that aren't listed anywhere else.
+// load should return a JSON array of Events; a nil slice means no events.
+// Events without an ID are given one from their date and venue, e.g. "manual-20130802-the-tin-pan".
+func JSONSource(load func() ([]byte, error)) Source {
+	return jsonSource(load)
+}
+
+type jsonSource func() ([]byte, error)
+
+func (js jsonSource) UpcomingContext(ctx context.Context, limit int) ([]Event, error) {
+	return js.events(limit, false)
+}
+
+func (js jsonSource) PastContext(ctx context.Context, limit int) ([]Event, error) {
+	return js.events(limit, true)
+}
+
+func (js jsonSource) events(limit int, past bool) ([]Event, error) {
+	b, err := js()
+	if err != nil || b == nil {
+		return nil, err
+	}
+	var all []Event
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	events := make([]Event, 0, len(all))
+	for _, e := range all {
+		if len(e.ID) == 0 {
+			// IDs end up in URLs and iCal UIDs, so they can't be left empty
+			e.ID = "manual-" + e.StartDate.Format("20060102") + "-" + Slug(e.Location.Name)
+		}
+		if e.Over(now) == past {
+			events = append(events, e)
+		}
+	}
+	sortEvents(events, past)
+	return limitEvents(events, limit), nil
+}
+
+// Combines Sources into one, dropping events listed by more than one of them.
+//
+// Events are the same if they start on the same day at venues within MergeRadius of each
+// other (or with the same name, when either lacks coordinates). The copy from the earliest
+// Source in the list is kept, along with ticket offers from a later one if it has none.
+//
+// Sources with Temporary errors are logged and skipped, unless all of them fail. Any other
+// error (e.g. a bad API key) is returned, so it doesn't quietly empty the list of shows.
+func Merge(sources ...Source) Source {
+	return merged(sources)
+}
+
+type merged []Source
+
+func (m merged) UpcomingContext(ctx context.Context, limit int) ([]Event, error) {
+	return m.events(ctx, limit, false)
+}
+
+func (m merged) PastContext(ctx context.Context, limit int) ([]Event, error) {
+	return m.events(ctx, limit, true)
+}
+
+func (m merged) events(ctx context.Context, limit int, past bool) ([]Event, error) {
+	results := make([]getResponse, len(m))
+	done := make(chan bool)
+	for i, s := range m {
+		go func(i int, s Source) {
+			if past {
+				results[i].Events, results[i].Err = s.PastContext(ctx, limit)
+			} else {
+				results[i].Events, results[i].Err = s.UpcomingContext(ctx, limit)
+			}
+			done <- true
+		}(i, s)
+	}
+	for range m {
+		<-done
+	}
+
+	var (
+		events []Event
+		err    error
+		failed int
+	)
+	for _, res := range results {
+		if res.Err != nil {
+			if !Temporary(res.Err) {
+				return nil, res.Err
+			}
+			log.Println("\x1b[1;31mSource Error:\x1b[0m", res.Err)
+			err = res.Err
+			failed++
+			continue
+		}
+	next:
+		for _, e := range res.Events {
+			for i, kept := range events {
+				if kept.Same(e) {
+					if len(kept.Offers) == 0 {
+						events[i].Offers = e.Offers
+					}
+					continue next
+				}
+			}
+			events = append(events, e)
+		}
+	}
+	if failed > 0 && failed == len(m) {
+		return nil, err
+	}
+	sortEvents(events, past)
+	return limitEvents(events, limit), nil
+}
+
+// Changes a list of events without modifying it, e.g. Overrides, VenueTypes or TimeZones
+type Applier interface {
+	Apply(events []Event) []Event
+}
+
+// Applies the Applier returned by load to every list of events from a Source.
+// Loading happens on every request, so load should be cheap (i.e. cached).
+func With(s Source, load func() (Applier, error)) Source {
+	return Transform(s, func(events []Event) ([]Event, error) {
+		a, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return a.Apply(events), nil
+	})
+}
+
+// Passes every list of events from a Source through fn, e.g. to correct or classify them.
+// fn must not modify the events it is given, as they may be shared with a cache.
+// All events are read from s and the limit applied after fn, since fn may drop some
+// (e.g. those hidden by Overrides).
+func Transform(s Source, fn func([]Event) ([]Event, error)) Source {
+	return transformed{s, fn}
+}
+
+type transformed struct {
+	Source
+	fn func([]Event) ([]Event, error)
+}
+
+func (t transformed) UpcomingContext(ctx context.Context, limit int) ([]Event, error) {
+	return t.apply(limit)(t.Source.UpcomingContext(ctx, 0))
+}
+
+func (t transformed) PastContext(ctx context.Context, limit int) ([]Event, error) {
+	return t.apply(limit)(t.Source.PastContext(ctx, 0))
+}
+
+func (t transformed) apply(limit int) func([]Event, error) ([]Event, error) {
+	return func(events []Event, err error) ([]Event, error) {
+		if err != nil {
+			return nil, err
+		}
+		if events, err = t.fn(events); err != nil {
+			return nil, err
+		}
+		return limitEvents(events, limit), nil
+	}
+}
+
+// Indicates two events, probably from different sources, describe the same show
+func (e Event) Same(o Event) bool {
+	if e.StartDate.Format("2006-01-02") != o.StartDate.Format("2006-01-02") {
+		return false
+	}
+	a, b := e.Location, o.Location
+	if a.Geo.Zero() || b.Geo.Zero() {
+		return strings.EqualFold(a.Name, b.Name)
+	}
+	return a.Geo.Distance(b.Geo) <= MergeRadius
+}
+
+// Upcoming shows go soonest first, past shows most recent first
+func sortEvents(events []Event, past bool) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if past {
+			return events[i].StartDate.After(events[j].StartDate)
+		}
+		return events[i].StartDate.Before(events[j].StartDate)
+	})
+}
+
+func limitEvents(events []Event, limit int) []Event {
+	if limit > 0 && len(events) > limit {
+		return events[:limit]
+	}
+	return events
+}
@@ -0,0 +1,135 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// A Source with the same events (or error) for any request
+type staticSource struct {
+	events []Event
+	err    error
+}
+
+func (s staticSource) UpcomingContext(ctx context.Context, limit int) ([]Event, error) {
+	return limitEvents(s.events, limit), s.err
+}
+
+func (s staticSource) PastContext(ctx context.Context, limit int) ([]Event, error) {
+	return limitEvents(s.events, limit), s.err
+}
+
+func show(id string, day int, venue string, lat, lng float32) Event {
+	return Event{
+		ID:        id,
+		StartDate: time.Date(2013, time.August, day, 20, 0, 0, 0, time.UTC),
+		Location:  Place{Name: venue, Geo: GeoCoordinates{Lat: lat, Lng: lng}},
+	}
+}
+
+func TestSame(t *testing.T) {
+	tinPan := show("1", 2, "The Tin Pan", 37.6022, -77.5498)
+	for _, c := range []struct {
+		o    Event
+		same bool
+	}{
+		{show("2", 2, "Tin Pan", 37.6030, -77.5490), true},      // ~100m away
+		{show("2", 2, "The Tin Pan", 37.5407, -77.4360), false}, // downtown Richmond, ~12km
+		{show("2", 3, "The Tin Pan", 37.6022, -77.5498), false}, // next day
+		{show("2", 2, "the tin pan", 0, 0), true},               // no coordinates, same name
+		{show("2", 2, "Capital Ale House", 0, 0), false},
+	} {
+		if got := tinPan.Same(c.o); got != c.same {
+			t.Errorf("Same(%q on %s at %v) = %v, want %v", c.o.Location.Name, c.o.StartDate.Format("Jan 2"), c.o.Location.Geo, got, c.same)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tickets := []Offer{{URL: "https://example.com/tickets"}}
+	songkick := staticSource{events: []Event{
+		show("sk-1", 2, "The Tin Pan", 37.6022, -77.5498),
+		show("sk-2", 9, "Floyd Country Store", 36.9115, -80.3200),
+	}}
+	bit := show("bit-1", 2, "Tin Pan", 37.6030, -77.5490)
+	bit.Offers = tickets
+	bandsintown := staticSource{events: []Event{
+		bit,
+		show("bit-2", 5, "Jammin Java", 38.9008, -77.2653),
+	}}
+	events, err := Merge(songkick, bandsintown).PastContext(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	if want := []string{"sk-2", "bit-2", "sk-1"}; len(ids) != len(want) || ids[0] != want[0] || ids[1] != want[1] || ids[2] != want[2] {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+	if len(events[2].Offers) != 1 || events[2].Offers[0].URL != tickets[0].URL {
+		t.Errorf("kept offers %v, want %v", events[2].Offers, tickets)
+	}
+	if len(songkick.events[0].Offers) != 0 {
+		t.Errorf("Merge changed the events of a Source")
+	}
+
+	events, err = Merge(songkick, bandsintown).PastContext(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].ID != "sk-2" {
+		t.Errorf("got %d events starting with %s, want the 2 most recent", len(events), events[0].ID)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	ok := staticSource{events: []Event{show("1", 2, "The Tin Pan", 0, 0)}}
+	down := staticSource{err: &PageError{Err: &StatusError{StatusCode: http.StatusServiceUnavailable}}}
+	unauthorized := staticSource{err: &PageError{Err: &StatusError{StatusCode: http.StatusUnauthorized}}}
+
+	events, err := Merge(ok, down).PastContext(context.Background(), 0)
+	if err != nil || len(events) != 1 {
+		t.Errorf("with a temporary error got %d events and %v, want the other source's event", len(events), err)
+	}
+	if _, err := Merge(down, down).PastContext(context.Background(), 0); !errors.Is(err, ErrServer) {
+		t.Errorf("with every source down got %v, want ErrServer", err)
+	}
+	if _, err := Merge(ok, unauthorized).PastContext(context.Background(), 0); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("with a bad key got %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestJSONSource(t *testing.T) {
+	s := JSONSource(func() ([]byte, error) {
+		return []byte(`[
+			{"Name": "Porch Party", "StartDate": "2013-08-02T19:00:00-04:00", "Location": {"Name": "The Tin Pan"}},
+			{"ID": "fest", "Name": "Fest", "StartDate": "2013-07-20T12:00:00-04:00"}
+		]`), nil
+	})
+	events, err := s.PastContext(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if id := events[0].ID; id != "manual-20130802-the-tin-pan" {
+		t.Errorf("got ID %q for an event without one", id)
+	}
+	if id := events[1].ID; id != "fest" {
+		t.Errorf("got ID %q, want fest", id)
+	}
+
+	none := JSONSource(func() ([]byte, error) { return nil, nil })
+	if events, err := none.UpcomingContext(context.Background(), 0); err != nil || len(events) != 0 {
+		t.Errorf("with nothing to load got %v, %v", events, err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Number of shows for something, e.g. a year or a venue
+type Count struct {
+	Name    string
+	Count   int
+	Percent int // of the largest Count in the same list, handy for bar charts
+}
+
+type Counts []Count
+
+// Returns at most the first n counts
+func (c Counts) Top(n int) Counts {
+	if len(c) > n {
+		return c[:n]
+	}
+	return c
+}
+
+// Summary of a gigography
+type Stats struct {
+	Total     int
+	First     Event
+	Last      Event
+	Years     Counts // in order by year
+	Regions   Counts // states and provinces, most played first
+	Countries Counts // most played first
+	Venues    Counts // most played first
+	CoBills   Counts // other artists on the bill, most shared first
+}
+
+// Computes statistics for events. Performers named artist are left out of CoBills.
+func ComputeStats(events []Event, artist string) Stats {
+	s := Stats{Total: len(events)}
+	if len(events) == 0 {
+		return s
+	}
+	years := newTally()
+	regions := newTally()
+	countries := newTally()
+	venues := newTally()
+	cobills := newTally()
+	for _, e := range events {
+		if s.First.StartDate.IsZero() || e.StartDate.Before(s.First.StartDate) {
+			s.First = e
+		}
+		if e.StartDate.After(s.Last.StartDate) {
+			s.Last = e
+		}
+		years.add(strconv.Itoa(e.StartDate.Year()), "")
+		a := e.Location.Address
+		if len(a.AddressRegion) > 0 {
+			regions.add(a.AddressRegion+", "+a.AddressCountry, a.AddressRegion)
+		}
+		countries.add(a.AddressCountry, "")
+		if len(e.Location.Name) > 0 {
+			key := e.Location.ID
+			if len(key) == 0 {
+				key = e.Location.Name
+			}
+			venues.add(key, e.Location.Name+", "+a.AddressLocality)
+		}
+		for _, p := range e.Performer {
+			if !strings.EqualFold(p.Name, artist) {
+				cobills.add(p.Name, "")
+			}
+		}
+	}
+	s.Years = years.counts()
+	sort.SliceStable(s.Years, func(i, j int) bool { return s.Years[i].Name < s.Years[j].Name })
+	s.Regions = mostFirst(regions.counts())
+	s.Countries = mostFirst(countries.counts())
+	s.Venues = mostFirst(venues.counts())
+	s.CoBills = mostFirst(cobills.counts())
+	return s
+}
+
+// Counts things by key, remembering the first display name seen for each
+type tally struct {
+	order []string
+	names map[string]string
+	count map[string]int
+}
+
+func newTally() *tally {
+	return &tally{names: make(map[string]string), count: make(map[string]int)}
+}
+
+func (t *tally) add(key, name string) {
+	if len(key) == 0 {
+		return
+	}
+	if _, ok := t.count[key]; !ok {
+		t.order = append(t.order, key)
+		if len(name) == 0 {
+			name = key
+		}
+		t.names[key] = name
+	}
+	t.count[key]++
+}
+
+func (t *tally) counts() Counts {
+	max := 0
+	for _, n := range t.count {
+		if n > max {
+			max = n
+		}
+	}
+	c := make(Counts, 0, len(t.order))
+	for _, key := range t.order {
+		c = append(c, Count{
+			Name:    t.names[key],
+			Count:   t.count[key],
+			Percent: t.count[key] * 100 / max,
+		})
+	}
+	return c
+}
+
+// Sorts counts with the largest first, then by name
+func mostFirst(c Counts) Counts {
+	sort.SliceStable(c, func(i, j int) bool {
+		if c[i].Count != c[j].Count {
+			return c[i].Count > c[j].Count
+		}
+		return c[i].Name < c[j].Name
+	})
+	return c
+}
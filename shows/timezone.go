@@ -0,0 +1,173 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Where to find the time zone of a venue, by IANA name (e.g. "America/Chicago").
+// Venues is checked first, then Regions, then Countries. US venues that aren't
+// configured fall back to their state's zone, then to a guess from their longitude.
+type TimeZones struct {
+	Venues    map[string]string // keyed by Place ID (the SongKick venue ID)
+	Regions   map[string]string // keyed by AddressRegion, e.g. "VA"
+	Countries map[string]string // keyed by AddressCountry, e.g. "UK"
+}
+
+// The part of an area at or east of a longitude that is in a time zone
+type zoneLine struct {
+	east float32
+	name string
+}
+
+// Time zone of all (or most) of each US state, by postal code
+var usStates = map[string]string{
+	"AL": "America/Chicago", "AK": "America/Anchorage", "AZ": "America/Phoenix",
+	"AR": "America/Chicago", "CA": "America/Los_Angeles", "CO": "America/Denver",
+	"CT": "America/New_York", "DC": "America/New_York", "DE": "America/New_York",
+	"FL": "America/New_York", "GA": "America/New_York", "HI": "Pacific/Honolulu",
+	"ID": "America/Boise", "IL": "America/Chicago", "IN": "America/Indiana/Indianapolis",
+	"IA": "America/Chicago", "KS": "America/Chicago", "KY": "America/New_York",
+	"LA": "America/Chicago", "ME": "America/New_York", "MD": "America/New_York",
+	"MA": "America/New_York", "MI": "America/Detroit", "MN": "America/Chicago",
+	"MS": "America/Chicago", "MO": "America/Chicago", "MT": "America/Denver",
+	"NE": "America/Chicago", "NV": "America/Los_Angeles", "NH": "America/New_York",
+	"NJ": "America/New_York", "NM": "America/Denver", "NY": "America/New_York",
+	"NC": "America/New_York", "ND": "America/Chicago", "OH": "America/New_York",
+	"OK": "America/Chicago", "OR": "America/Los_Angeles", "PA": "America/New_York",
+	"RI": "America/New_York", "SC": "America/New_York", "SD": "America/Chicago",
+	"TN": "America/Chicago", "TX": "America/Chicago", "UT": "America/Denver",
+	"VT": "America/New_York", "VA": "America/New_York", "WA": "America/Los_Angeles",
+	"WV": "America/New_York", "WI": "America/Chicago", "WY": "America/Denver",
+}
+
+// States split between time zones, east to west, for venues with coordinates.
+// The lines are rough; towns right on a boundary belong in TimeZones.Venues.
+var usSplitStates = map[string][]zoneLine{
+	"FL": {{-85, "America/New_York"}, {-180, "America/Chicago"}},
+	"ID": {{-116.5, "America/Boise"}, {-180, "America/Los_Angeles"}},
+	"IN": {{-87.3, "America/Indiana/Indianapolis"}, {-180, "America/Chicago"}},
+	"KS": {{-101.5, "America/Chicago"}, {-180, "America/Denver"}},
+	"KY": {{-86, "America/New_York"}, {-180, "America/Chicago"}},
+	"MI": {{-87.6, "America/Detroit"}, {-180, "America/Menominee"}},
+	"ND": {{-101.5, "America/Chicago"}, {-180, "America/Denver"}},
+	"NE": {{-101.4, "America/Chicago"}, {-180, "America/Denver"}},
+	"OR": {{-117.5, "America/Boise"}, {-180, "America/Los_Angeles"}},
+	"SD": {{-100.6, "America/Chicago"}, {-180, "America/Denver"}},
+	"TN": {{-85.4, "America/New_York"}, {-180, "America/Chicago"}},
+	"TX": {{-105, "America/Chicago"}, {-180, "America/Denver"}},
+}
+
+// Rough boundaries between the US time zones, by longitude, for venues without a state.
+// Good enough for most venues; the exceptions belong in TimeZones.Venues or Regions.
+var usZones = []zoneLine{
+	{-87.5, "America/New_York"},
+	{-101.5, "America/Chicago"},
+	{-114.5, "America/Denver"},
+	{-130, "America/Los_Angeles"},
+	{-155, "America/Anchorage"},
+	{-180, "Pacific/Honolulu"},
+}
+
+// Returns the IANA name of the time zone for a place, or an empty string if it is unknown
+func (tz TimeZones) Name(p Place) string {
+	if name, ok := tz.Venues[p.ID]; ok && len(p.ID) > 0 {
+		return name
+	}
+	if name, ok := tz.Regions[p.Address.AddressRegion]; ok {
+		return name
+	}
+	if name, ok := tz.Countries[p.Address.AddressCountry]; ok {
+		return name
+	}
+	switch p.Address.AddressCountry {
+	case "US", "USA", "United States":
+	default:
+		return ""
+	}
+	state := strings.ToUpper(p.Address.AddressRegion)
+	if lines, ok := usSplitStates[state]; ok && !p.Geo.Zero() {
+		return byLongitude(lines, p.Geo)
+	}
+	if name, ok := usStates[state]; ok {
+		return name
+	}
+	if p.Geo.Zero() {
+		return ""
+	}
+	return byLongitude(usZones, p.Geo)
+}
+
+func byLongitude(lines []zoneLine, g GeoCoordinates) string {
+	for _, z := range lines {
+		if g.Lng >= z.east {
+			return z.name
+		}
+	}
+	return ""
+}
+
+// Returns a copy of events with each TimeZone set, and dates moved into that zone.
+//
+// Times with an offset keep their instant. Times without one (which read as UTC) and
+// all day events keep their wall clock, since those are local to the venue.
+func (tz TimeZones) Apply(events []Event) []Event {
+	zoned := make([]Event, len(events))
+	copy(zoned, events)
+	for i, e := range zoned {
+		name := e.TimeZone
+		if len(name) == 0 {
+			name = tz.Name(e.Location)
+		}
+		loc, err := loadLocation(name)
+		if err != nil {
+			log.Println("\x1b[1;31mTime Zone:\x1b[0m", e.ID, err)
+			continue
+		}
+		if loc == nil {
+			continue
+		}
+		zoned[i].TimeZone = name
+		zoned[i].StartDate = inZone(e.StartDate, loc, e.AllDay)
+		zoned[i].EndDate = inZone(e.EndDate, loc, e.AllDay)
+	}
+	return zoned
+}
+
+func inZone(t time.Time, loc *time.Location, allDay bool) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	if allDay || t.Location() == time.UTC {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	}
+	return t.In(loc)
+}
+
+var locations = struct {
+	sync.Mutex
+	m map[string]*time.Location
+}{m: make(map[string]*time.Location)}
+
+// Like time.LoadLocation, but remembers each location, and returns nil for an empty name
+func loadLocation(name string) (*time.Location, error) {
+	if len(name) == 0 {
+		return nil, nil
+	}
+	locations.Lock()
+	defer locations.Unlock()
+	if loc, ok := locations.m[name]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.m[name] = loc
+	return loc, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"testing"
+	"time"
+)
+
+func usPlace(id, region string, lat, lng float32) Place {
+	return Place{
+		ID:      id,
+		Address: Address{AddressRegion: region, AddressCountry: "US"},
+		Geo:     GeoCoordinates{Lat: lat, Lng: lng},
+	}
+}
+
+func TestTimeZoneName(t *testing.T) {
+	tz := TimeZones{
+		Venues:    map[string]string{"77": "America/Chicago"},
+		Regions:   map[string]string{"ON": "America/Toronto"},
+		Countries: map[string]string{"UK": "Europe/London"},
+	}
+	for _, c := range []struct {
+		where string
+		p     Place
+		want  string
+	}{
+		{"Tucson", usPlace("", "AZ", 32.2217, -110.9265), "America/Phoenix"},
+		{"Nashville", usPlace("", "TN", 36.1627, -86.7816), "America/Chicago"},
+		{"Knoxville", usPlace("", "TN", 35.9606, -83.9207), "America/New_York"},
+		{"Birmingham", usPlace("", "AL", 33.5186, -86.8104), "America/Chicago"},
+		{"Louisville", usPlace("", "KY", 38.2527, -85.7585), "America/New_York"},
+		{"Bowling Green", usPlace("", "KY", 36.9685, -86.4808), "America/Chicago"},
+		{"Pensacola", usPlace("", "FL", 30.4213, -87.2169), "America/Chicago"},
+		{"El Paso", usPlace("", "TX", 31.7619, -106.4850), "America/Denver"},
+		{"Richmond", usPlace("", "VA", 37.5407, -77.4360), "America/New_York"},
+		{"a Tennessee venue without coordinates", usPlace("", "tn", 0, 0), "America/Chicago"},
+		{"a venue without a state", usPlace("", "", 39.7392, -104.9903), "America/Denver"},
+		{"a venue without a state or coordinates", usPlace("", "", 0, 0), ""},
+		{"a configured venue", usPlace("77", "TN", 35.9606, -83.9207), "America/Chicago"},
+		{"a configured region", Place{Address: Address{AddressRegion: "ON", AddressCountry: "CA"}}, "America/Toronto"},
+		{"a configured country", Place{Address: Address{AddressCountry: "UK"}}, "Europe/London"},
+		{"anywhere else", Place{Address: Address{AddressCountry: "IE"}, Geo: GeoCoordinates{Lat: 53.3, Lng: -6.3}}, ""},
+	} {
+		if got := tz.Name(c.p); got != c.want {
+			t.Errorf("%s is in %q, want %q", c.where, got, c.want)
+		}
+	}
+}
+
+func TestTimeZoneApply(t *testing.T) {
+	tucson := usPlace("", "AZ", 32.2217, -110.9265)
+	richmond := usPlace("", "VA", 37.5407, -77.4360)
+	events := []Event{
+		// a Bandsintown time, with no offset
+		{ID: "wall", StartDate: time.Date(2013, time.July, 20, 20, 0, 0, 0, time.UTC), Location: tucson},
+		// a SongKick time, with one
+		{ID: "offset", StartDate: time.Date(2013, time.July, 20, 20, 0, 0, 0, time.FixedZone("", -4*3600)), Location: richmond},
+		{ID: "all-day", AllDay: true, StartDate: time.Date(2013, time.July, 20, 0, 0, 0, 0, time.UTC), Location: tucson},
+		{ID: "set", TimeZone: "America/Chicago", StartDate: time.Date(2013, time.July, 20, 20, 0, 0, 0, time.UTC), Location: tucson},
+		{ID: "unknown", StartDate: time.Date(2013, time.July, 20, 20, 0, 0, 0, time.UTC), Location: Place{Address: Address{AddressCountry: "IE"}}},
+	}
+	zoned := TimeZones{}.Apply(events)
+	for i, want := range []struct {
+		zone  string
+		start time.Time
+	}{
+		{"America/Phoenix", time.Date(2013, time.July, 21, 3, 0, 0, 0, time.UTC)},
+		{"America/New_York", time.Date(2013, time.July, 21, 0, 0, 0, 0, time.UTC)},
+		{"America/Phoenix", time.Date(2013, time.July, 20, 7, 0, 0, 0, time.UTC)},
+		{"America/Chicago", time.Date(2013, time.July, 21, 1, 0, 0, 0, time.UTC)},
+		{"", time.Date(2013, time.July, 20, 20, 0, 0, 0, time.UTC)},
+	} {
+		e := zoned[i]
+		if e.TimeZone != want.zone || !e.StartDate.Equal(want.start) {
+			t.Errorf("%s: got %v in %q, want %v in %q", e.ID, e.StartDate.UTC(), e.TimeZone, want.start, want.zone)
+		}
+	}
+	if zoned[1].StartDate.Hour() != 20 {
+		t.Errorf("offset: got %v, want 8pm in New York", zoned[1].StartDate)
+	}
+	if zoned[2].StartDate.Day() != 20 || zoned[2].StartDate.Hour() != 0 {
+		t.Errorf("all-day: got %v, want the same day", zoned[2].StartDate)
+	}
+	if events[0].TimeZone != "" {
+		t.Errorf("Apply changed the events it was given")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2013 Jesse Allen. All rights reserved
+// Released under the MIT license found in the LICENSE file.
+
+package shows
+
+import (
+	"sort"
+	"strings"
+)
+
+// EventTypes for particular venues, keyed by Place ID (the SongKick venue ID).
+// SongKick only knows about festivals and concerts, so this fills in the rest.
+type VenueTypes map[string]EventType
+
+// Returns a copy of events, with the type of each non-festival event set from its venue
+func (vt VenueTypes) Apply(events []Event) []Event {
+	typed := make([]Event, len(events))
+	copy(typed, events)
+	for i, e := range typed {
+		if t, ok := vt[e.Location.ID]; ok && e.Type != Festival {
+			typed[i].Type = t
+		}
+	}
+	return typed
+}
+
+// Returns only the events of the given type
+func FilterType(events []Event, t EventType) []Event {
+	filtered := make([]Event, 0, len(events))
+	for _, e := range events {
+		if e.Type == t {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// Events of a single type
+type TypeGroup struct {
+	Type   EventType
+	Events []Event
+}
+
+// Groups events by type, in the order of EventTypes. Empty groups are left out.
+func GroupByType(events []Event) []TypeGroup {
+	groups := make([]TypeGroup, 0, len(EventTypes))
+	for _, t := range EventTypes {
+		if filtered := FilterType(events, t); len(filtered) > 0 {
+			groups = append(groups, TypeGroup{t, filtered})
+		}
+	}
+	return groups
+}
+
+// Every show at a single venue
+type Venue struct {
+	Place  Place   // as of the most recent show
+	Events []Event // most recent first
+}
+
+// Groups events by venue, using the Place ID, leaving out events at unknown venues.
+// Venues are ordered by name.
+func GroupByVenue(events []Event) []Venue {
+	index := make(map[string]int)
+	venues := make([]Venue, 0)
+	for _, e := range events {
+		id := e.Location.ID
+		if len(id) == 0 {
+			continue
+		}
+		i, ok := index[id]
+		if !ok {
+			i = len(venues)
+			index[id] = i
+			venues = append(venues, Venue{})
+		}
+		venues[i].Events = append(venues[i].Events, e)
+	}
+	for i, v := range venues {
+		sortEvents(v.Events, true)
+		venues[i].Place = v.Events[0].Location
+	}
+	sort.Slice(venues, func(i, j int) bool {
+		return strings.ToLower(venues[i].Place.Name) < strings.ToLower(venues[j].Place.Name)
+	})
+	return venues
+}
@@ -0,0 +1,50 @@
+/* Tour Map stuff */
+(function(){
+  var $map = $('#tour-map');
+  if (!$map.length || typeof L === 'undefined') {
+    return;
+  }
+  var map = L.map($map[0]).setView([37.5, -80], 5);
+  L.tileLayer('http://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png', {
+    attribution: '&copy; <a href="http://osm.org/copyright">OpenStreetMap</a> contributors'
+  }).addTo(map);
+
+  $.getJSON($map.data('geojson'), function(data){
+    var layer = L.geoJson(data, {
+      pointToLayer: function(feature, latlng){
+        return L.circleMarker(latlng, {
+          radius: 6,
+          weight: 1,
+          color: '#fff',
+          fillOpacity: 0.8,
+          fillColor: feature.properties.upcoming ? '#d9534f' : '#428bca'
+        });
+      },
+      onEachFeature: function(feature, marker){
+        var p = feature.properties,
+        $popup = $('<div/>');
+        $('<strong/>').text(p.name).appendTo($popup);
+        $('<div/>').text(p.date + ' · ' + p.venue).appendTo($popup);
+        $('<div class="text-muted"/>').text([p.locality, p.region].join(', ')).appendTo($popup);
+        marker.bindPopup($popup[0]);
+      }
+    }).addTo(map);
+    if (data.features.length) {
+      map.fitBounds(layer.getBounds(), {padding: [20, 20]});
+    }
+  });
+})();
+
+/* Single show map */
+(function(){
+  var $map = $('#event-map');
+  if (!$map.length || typeof L === 'undefined') {
+    return;
+  }
+  var latlng = [$map.data('lat'), $map.data('lng')];
+  var map = L.map($map[0]).setView(latlng, 14);
+  L.tileLayer('http://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png', {
+    attribution: '&copy; <a href="http://osm.org/copyright">OpenStreetMap</a> contributors'
+  }).addTo(map);
+  L.marker(latlng).addTo(map);
+})();
@@ -0,0 +1,13 @@
+/* Shows Near Me stuff */
+$('.shows-near-me').on('click', function(e){
+  if (!navigator.geolocation) {
+    return; // fall back to the plain shows page
+  }
+  e.preventDefault();
+  var $btn = $(this).addClass('disabled');
+  navigator.geolocation.getCurrentPosition(function(pos){
+    window.location.href = '/shows/?near=' + pos.coords.latitude.toFixed(3) + ',' + pos.coords.longitude.toFixed(3) + '#near';
+  }, function(){
+    $btn.removeClass('disabled').text('Could not find your location');
+  });
+});